@@ -0,0 +1,52 @@
+package address
+
+import "regexp"
+
+// Kinds of address a vehicle can't be garaged at
+const (
+	PostOfficeBox   = "PO box"
+	PrivateMailbox  = "private mailbox"
+	GeneralDelivery = "general delivery"
+	MilitaryMail    = "military APO/FPO"
+)
+
+var (
+	// PO BOX 12, P.O. Box 12, POBOX 12, Post Office Box 12, POB 12, BOX 12
+	poBoxPattern = regexp.MustCompile(`(^|\s)(P\s?O|POST\s+OFFICE)\s?BOX(\s|$)|^(POB|BOX)\s+[0-9A-Z]`)
+	// PMB 123, Private Mailbox 123
+	pmbPattern = regexp.MustCompile(`(^|\s)PMB(\s|$)|PRIVATE\s+MAIL\s?BOX`)
+	// General Delivery
+	generalDeliveryPattern = regexp.MustCompile(`GENERAL\s+DELIVERY`)
+	// PSC 1234 BOX 5678, CMR 450 BOX 1234, UNIT 2050 BOX 4190
+	militaryPattern = regexp.MustCompile(`^(PSC|CMR|UNIT)\s+[0-9]+\s+BOX\s+[0-9]+`)
+)
+
+// militaryCities and militaryStates are the city and state used for APO, FPO and DPO addresses
+var (
+	militaryCities = map[string]bool{"APO": true, "FPO": true, "DPO": true}
+	militaryStates = map[string]bool{"AA": true, "AE": true, "AP": true}
+)
+
+// NonGarageable returns the kind of mail-only address, a PO box, private mailbox, general delivery
+// or military APO/FPO address, that a vehicle can't be garaged at. Blank means the address may be
+// garageable.
+func NonGarageable(line1, line2, city, state string) string {
+	for _, line := range []string{cleanText(line1), cleanText(line2)} {
+		switch {
+		case len(line) == 0:
+		case generalDeliveryPattern.MatchString(line):
+			return GeneralDelivery
+		case militaryPattern.MatchString(line):
+			return MilitaryMail
+		case pmbPattern.MatchString(line):
+			return PrivateMailbox
+		case poBoxPattern.MatchString(line):
+			return PostOfficeBox
+		}
+	}
+
+	if militaryCities[cleanText(city)] || militaryStates[cleanText(state)] {
+		return MilitaryMail
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package address
+
+import "testing"
+
+func TestNonGarageable(t *testing.T) {
+	tests := []struct {
+		name                      string
+		line1, line2, city, state string
+		want                      string
+	}{
+		{"street address", "123 Main St", "", "Columbus", "OH", ""},
+		{"street with apartment", "123 Main St", "Apt 4", "Columbus", "OH", ""},
+		{"PO BOX", "PO BOX 12", "", "Columbus", "OH", PostOfficeBox},
+		{"P.O. Box", "P.O. Box 12", "", "Columbus", "OH", PostOfficeBox},
+		{"P O Box", "P O Box 12", "", "Columbus", "OH", PostOfficeBox},
+		{"POBOX", "POBOX 12", "", "Columbus", "OH", PostOfficeBox},
+		{"Post Office Box", "Post Office Box 12", "", "Columbus", "OH", PostOfficeBox},
+		{"POB", "POB 12", "", "Columbus", "OH", PostOfficeBox},
+		{"Box", "Box 12", "", "Columbus", "OH", PostOfficeBox},
+		{"lowercase po box", "po box 12", "", "Columbus", "OH", PostOfficeBox},
+		{"PO box on line two", "123 Main St", "PO Box 12", "Columbus", "OH", PostOfficeBox},
+		{"box in a street name", "12 Boxwood Ln", "", "Columbus", "OH", ""},
+		{"box after a house number", "12 Box Elder St", "", "Columbus", "OH", ""},
+		{"PO in a street name", "12 Pond Rd", "", "Columbus", "OH", ""},
+		{"PMB", "123 Main St", "PMB 456", "Columbus", "OH", PrivateMailbox},
+		{"Private Mailbox", "123 Main St Private Mailbox 456", "", "Columbus", "OH", PrivateMailbox},
+		{"general delivery", "General Delivery", "", "Columbus", "OH", GeneralDelivery},
+		{"PSC box", "PSC 1234 Box 5678", "", "APO", "AE", MilitaryMail},
+		{"CMR box", "CMR 450 Box 1234", "", "Columbus", "OH", MilitaryMail},
+		{"unit box", "Unit 2050 Box 4190", "", "Columbus", "OH", MilitaryMail},
+		{"APO city", "123 Main St", "", "APO", "NY", MilitaryMail},
+		{"military state", "123 Main St", "", "Columbus", "AP", MilitaryMail},
+		{"blank", "", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NonGarageable(tt.line1, tt.line2, tt.city, tt.state); got != tt.want {
+				t.Errorf("NonGarageable(%q, %q, %q, %q) = %q, want %q", tt.line1, tt.line2, tt.city, tt.state, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package address
+
+import (
+	"strings"
+	"unicode"
+)
+
+// streetSuffixes maps street suffixes to their USPS standard abbreviation (USPS Publication 28, appendix C1)
+var streetSuffixes = map[string]string{
+	"ALLEY": "ALY", "ANNEX": "ANX", "ARCADE": "ARC", "AVENUE": "AVE", "AV": "AVE", "AVEN": "AVE",
+	"BAYOU": "BYU", "BEACH": "BCH", "BEND": "BND", "BLUFF": "BLF", "BOTTOM": "BTM", "BOULEVARD": "BLVD", "BOUL": "BLVD",
+	"BRANCH": "BR", "BRIDGE": "BRG", "BROOK": "BRK", "BURG": "BG", "BYPASS": "BYP",
+	"CAMP": "CP", "CANYON": "CYN", "CAPE": "CPE", "CAUSEWAY": "CSWY", "CENTER": "CTR", "CENTRE": "CTR",
+	"CIRCLE": "CIR", "CIRCL": "CIR", "CLIFF": "CLF", "CLUB": "CLB", "COMMON": "CMN", "CORNER": "COR", "CORNERS": "CORS",
+	"COURSE": "CRSE", "COURT": "CT", "COURTS": "CTS", "COVE": "CV", "CREEK": "CRK", "CRESCENT": "CRES", "CREST": "CRST",
+	"CROSSING": "XING", "CURVE": "CURV",
+	"DALE": "DL", "DAM": "DM", "DIVIDE": "DV", "DRIVE": "DR", "DRIV": "DR", "DRV": "DR",
+	"ESTATE": "EST", "ESTATES": "ESTS", "EXPRESSWAY": "EXPY", "EXTENSION": "EXT",
+	"FALLS": "FLS", "FERRY": "FRY", "FIELD": "FLD", "FIELDS": "FLDS", "FLAT": "FLT", "FORD": "FRD", "FOREST": "FRST",
+	"FORGE": "FRG", "FORK": "FRK", "FORT": "FT", "FREEWAY": "FWY",
+	"GARDEN": "GDN", "GARDENS": "GDNS", "GATEWAY": "GTWY", "GLEN": "GLN", "GREEN": "GRN", "GROVE": "GRV",
+	"HARBOR": "HBR", "HAVEN": "HVN", "HEIGHTS": "HTS", "HIGHWAY": "HWY", "HILL": "HL", "HILLS": "HLS", "HOLLOW": "HOLW",
+	"ISLAND": "IS", "ISLANDS": "ISS",
+	"JUNCTION": "JCT",
+	"KNOLL":    "KNL", "KNOLLS": "KNLS",
+	"LAKE": "LK", "LAKES": "LKS", "LANDING": "LNDG", "LANE": "LN", "LIGHT": "LGT", "LOCK": "LCK", "LODGE": "LDG",
+	"MANOR": "MNR", "MEADOW": "MDW", "MEADOWS": "MDWS", "MILL": "ML", "MILLS": "MLS", "MISSION": "MSN",
+	"MOTORWAY": "MTWY", "MOUNT": "MT", "MOUNTAIN": "MTN",
+	"ORCHARD": "ORCH", "OVERPASS": "OPAS",
+	"PARKWAY": "PKWY", "PARKWY": "PKWY", "PIKE": "PIKE", "PINES": "PNES", "PLACE": "PL", "PLAIN": "PLN", "PLAINS": "PLNS",
+	"PLAZA": "PLZ", "POINT": "PT", "POINTS": "PTS", "PORT": "PRT", "PRAIRIE": "PR",
+	"RANCH": "RNCH", "RAPIDS": "RPDS", "REST": "RST", "RIDGE": "RDG", "RIVER": "RIV", "ROAD": "RD", "ROUTE": "RTE",
+	"SHOAL": "SHL", "SHORE": "SHR", "SHORES": "SHRS", "SKYWAY": "SKWY", "SPRING": "SPG", "SPRINGS": "SPGS", "SQUARE": "SQ",
+	"STATION": "STA", "STREAM": "STRM", "STREET": "ST", "STR": "ST", "SUMMIT": "SMT",
+	"TERRACE": "TER", "TRACE": "TRCE", "TRAIL": "TRL", "TRAILER": "TRLR", "TUNNEL": "TUNL", "TURNPIKE": "TPKE",
+	"UNION":  "UN",
+	"VALLEY": "VLY", "VIADUCT": "VIA", "VIEW": "VW", "VILLAGE": "VLG", "VILLE": "VL", "VISTA": "VIS",
+	"WELL": "WL", "WELLS": "WLS",
+}
+
+// unitDesignators maps secondary unit designators that are followed by a unit number to their
+// USPS standard abbreviation (USPS Publication 28, appendix C2)
+var unitDesignators = map[string]string{
+	"APARTMENT": "APT", "BUILDING": "BLDG", "DEPARTMENT": "DEPT", "FLOOR": "FL", "HANGAR": "HNGR",
+	"ROOM": "RM", "SPACE": "SPC", "SUITE": "STE", "TRAILER": "TRLR",
+	"APT": "APT", "BLDG": "BLDG", "DEPT": "DEPT", "FL": "FL", "HNGR": "HNGR", "LOT": "LOT", "PIER": "PIER",
+	"RM": "RM", "SLIP": "SLIP", "SPC": "SPC", "STE": "STE", "STOP": "STOP", "TRLR": "TRLR", "UNIT": "UNIT",
+}
+
+// directionals maps directions to their USPS standard abbreviation
+var directionals = map[string]string{
+	"NORTH": "N", "SOUTH": "S", "EAST": "E", "WEST": "W",
+	"NORTHEAST": "NE", "NORTHWEST": "NW", "SOUTHEAST": "SE", "SOUTHWEST": "SW",
+}
+
+// cleanText folds to ASCII, uppercases, replaces punctuation other than '#', '-', '/' and '&'
+// with spaces and collapses whitespace
+func cleanText(s string) string {
+	s = strings.ToUpper(Fold(s))
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return r
+		case r == '#' || r == '-' || r == '/' || r == '&':
+			return r
+		case r == '\'':
+			return -1 // O'BRIEN -> OBRIEN
+		}
+		return ' '
+	}, s)
+	s = strings.ReplaceAll(s, "#", " # ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// isUnitNumber checks if a word looks like a secondary unit number, e.g. 5, 12B or C
+func isUnitNumber(word string) bool {
+	return len(word) == 1 || strings.IndexFunc(word, unicode.IsDigit) >= 0
+}
+
+// unitStart returns the index of the first secondary unit designator in words, or len(words) if none
+func unitStart(words []string) int {
+	for i, w := range words {
+		if w == "#" {
+			return i
+		}
+		if _, ok := unitDesignators[w]; ok && i < len(words)-1 && (words[i+1] == "#" || isUnitNumber(words[i+1])) {
+			return i
+		}
+	}
+	return len(words)
+}
+
+// NormalizeLine returns an address line in USPS standard form: ASCII, uppercase, no punctuation,
+// single spaced, with the street suffix, directionals and unit designators abbreviated
+func NormalizeLine(line string) string {
+	words := strings.Fields(cleanText(line))
+	end := unitStart(words)
+
+	// street: house number, optional pre-directional, name, suffix, optional post-directional
+	last := end - 1
+	if last >= 2 {
+		if abbr, ok := directionals[words[last]]; ok {
+			words[last] = abbr
+			last--
+		}
+	}
+	if last >= 2 {
+		if abbr, ok := streetSuffixes[words[last]]; ok {
+			words[last] = abbr
+		}
+	}
+	if last > 2 {
+		if abbr, ok := directionals[words[1]]; ok {
+			words[1] = abbr
+		}
+	}
+
+	// secondary units: "APARTMENT # 5" -> "APT 5"
+	var units []string
+	for i := end; i < len(words); i++ {
+		if abbr, ok := unitDesignators[words[i]]; ok {
+			units = append(units, abbr)
+			if i+1 < len(words) && words[i+1] == "#" {
+				i++
+			}
+			continue
+		}
+		units = append(units, words[i])
+	}
+
+	return strings.Join(append(words[:end], units...), " ")
+}
+
+// NormalizeCity returns a city name folded to ASCII, uppercase, without punctuation and single spaced
+func NormalizeCity(city string) string {
+	return cleanText(city)
+}
+
+// NormalizeState returns a state or province code uppercase and trimmed
+func NormalizeState(state string) string {
+	return cleanText(state)
+}
+
+// SameLine compares two address lines after normalization
+func SameLine(a, b string) bool {
+	return NormalizeLine(a) == NormalizeLine(b)
+}
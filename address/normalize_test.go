@@ -0,0 +1,66 @@
+package address
+
+import "testing"
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"123 Main Street", "123 MAIN ST"},
+		{"  123   main   st. ", "123 MAIN ST"},
+		{"123 North Main Street", "123 N MAIN ST"},
+		{"123 Main Street North", "123 MAIN ST N"},
+		{"123 N. Main St., Apt. 4", "123 N MAIN ST APT 4"},
+		{"123 Main Street Apartment 4B", "123 MAIN ST APT 4B"},
+		{"123 Main St Apartment # 5", "123 MAIN ST APT 5"},
+		{"123 Main St #5", "123 MAIN ST # 5"},
+		{"500 Oak Boulevard Suite 200", "500 OAK BLVD STE 200"},
+		{"12 Park Avenue Floor 3", "12 PARK AVE FL 3"},
+		{"1 Lake Shore Drive Unit C", "1 LAKE SHORE DR UNIT C"},
+		{"42 O'Brien Court", "42 OBRIEN CT"},
+		{"7 Rue Saint-Étienne", "7 RUE SAINT-ETIENNE"},
+		{"North Street", "NORTH STREET"}, // no house number, too short to tell the suffix from the name
+		{"10 North", "10 NORTH"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := NormalizeLine(tt.line); got != tt.want {
+				t.Errorf("NormalizeLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameLine(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"123 Main Street", "123 MAIN ST", true},
+		{"123 Main Street, Apartment 4", "123 Main St Apt 4", true},
+		{"123 North Main Street", "123 N Main St", true},
+		{"123 Main Street", "124 Main Street", false},
+		{"123 Main Street Apt 4", "123 Main Street Apt 5", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameLine(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameLine(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCityAndState(t *testing.T) {
+	if got := NormalizeCity("  Saint-Jérôme "); got != "SAINT-JEROME" {
+		t.Errorf("NormalizeCity = %q, want %q", got, "SAINT-JEROME")
+	}
+	if got := NormalizeCity("St. Louis"); got != "ST LOUIS" {
+		t.Errorf("NormalizeCity = %q, want %q", got, "ST LOUIS")
+	}
+	if got := NormalizeState(" oh "); got != "OH" {
+		t.Errorf("NormalizeState = %q, want %q", got, "OH")
+	}
+}
@@ -0,0 +1,121 @@
+package address
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Country codes with postal code rules
+const (
+	CountryUS = "US"
+	CountryCA = "CA"
+	CountryMX = "MX"
+)
+
+// Country returns the ISO country code for an ADP country code, treating blank as US
+func Country(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch code {
+	case "":
+		return CountryUS
+	case "USA":
+		return CountryUS
+	case "CAN":
+		return CountryCA
+	case "MEX":
+		return CountryMX
+	}
+	return code
+}
+
+// digitsOnly removes everything but digits from s
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizePostalCode returns the postal code in the canonical format for country:
+//   - US: 12345, or 12345-6789 when keepZIP4 is set and the +4 is present
+//   - CA: A1A 1A1
+//   - MX: 5 digits
+//
+// Postal codes for other countries are trimmed and uppercased. An error is returned when the
+// postal code is not valid for the country.
+func NormalizePostalCode(country, postalCode string, keepZIP4 bool) (string, error) {
+	postalCode = strings.ToUpper(strings.TrimSpace(postalCode))
+
+	switch Country(country) {
+	case CountryUS:
+		digits := digitsOnly(postalCode)
+		if len(digits) != len(strings.Map(dropSeparators, postalCode)) || (len(digits) != 5 && len(digits) != 9) {
+			return "", fmt.Errorf("invalid US ZIP code '%s'", postalCode)
+		}
+		if keepZIP4 && len(digits) == 9 {
+			return digits[:5] + "-" + digits[5:], nil
+		}
+		return digits[:5], nil
+
+	case CountryCA:
+		compact := strings.Map(dropSeparators, postalCode)
+		if !isCanadianPostalCode(compact) {
+			return "", fmt.Errorf("invalid Canadian postal code '%s'", postalCode)
+		}
+		return compact[:3] + " " + compact[3:], nil
+
+	case CountryMX:
+		digits := digitsOnly(postalCode)
+		if len(digits) != 5 || len(digits) != len(strings.Map(dropSeparators, postalCode)) {
+			return "", fmt.Errorf("invalid Mexican postal code '%s'", postalCode)
+		}
+		return digits, nil
+	}
+
+	return postalCode, nil
+}
+
+// dropSeparators is a strings.Map function removing spaces and dashes
+func dropSeparators(r rune) rune {
+	if unicode.IsSpace(r) || r == '-' {
+		return -1
+	}
+	return r
+}
+
+// isCanadianPostalCode checks for the A1A1A1 pattern, without separators
+func isCanadianPostalCode(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for i, r := range s {
+		if i%2 == 0 {
+			// letters D, F, I, O, Q and U are never used
+			if r < 'A' || r > 'Z' || strings.ContainsRune("DFIOQU", r) {
+				return false
+			}
+		} else if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SamePostalCode checks if the current postal code already matches the incoming one for country
+// after normalization. US ZIP codes are compared on the first 5 digits unless keepZIP4 is set and
+// the incoming ZIP code carries a +4. Postal codes that do not normalize are compared as plain text.
+func SamePostalCode(country, current, incoming string, keepZIP4 bool) bool {
+	nc, errC := NormalizePostalCode(country, current, true)
+	ni, errI := NormalizePostalCode(country, incoming, true)
+	if errC != nil || errI != nil {
+		return strings.EqualFold(strings.TrimSpace(current), strings.TrimSpace(incoming))
+	}
+	if Country(country) == CountryUS && (!keepZIP4 || len(ni) == 5) {
+		return nc[:5] == ni[:5]
+	}
+	return nc == ni
+}
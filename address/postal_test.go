@@ -0,0 +1,82 @@
+package address
+
+import "testing"
+
+func TestNormalizePostalCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		country    string
+		postalCode string
+		keepZIP4   bool
+		want       string
+		wantErr    bool
+	}{
+		{"US 5 digit", "US", "43215", false, "43215", false},
+		{"US blank country", "", "43215", false, "43215", false},
+		{"US three letter country", "USA", " 43215 ", false, "43215", false},
+		{"US ZIP+4 dropped", "US", "43215-1234", false, "43215", false},
+		{"US ZIP+4 kept", "US", "43215-1234", true, "43215-1234", false},
+		{"US ZIP+4 without dash kept", "US", "432151234", true, "43215-1234", false},
+		{"US ZIP+4 with space", "US", "43215 1234", true, "43215-1234", false},
+		{"US 5 digit with keep ZIP+4", "US", "43215", true, "43215", false},
+		{"US leading zero kept", "US", "02134", false, "02134", false},
+		{"US leading zero ZIP+4", "US", "00501-0001", true, "00501-0001", false},
+		{"US leading zero lost", "US", "2134", false, "", true},
+		{"US too long", "US", "432151", false, "", true},
+		{"US letters", "US", "4321A", false, "", true},
+		{"US blank", "US", "", false, "", true},
+		{"CA spaced", "CA", "K1A 0B1", false, "K1A 0B1", false},
+		{"CA compact lowercase", "CAN", "k1a0b1", false, "K1A 0B1", false},
+		{"CA dashed", "CA", "M5V-3L9", false, "M5V 3L9", false},
+		{"CA unused letter", "CA", "D1A 0B1", false, "", true},
+		{"CA wrong pattern", "CA", "K1A 0BB", false, "", true},
+		{"CA US ZIP", "CA", "43215", false, "", true},
+		{"MX", "MEX", "06600", false, "06600", false},
+		{"MX too short", "MX", "0660", false, "", true},
+		{"other country", "GB", " sw1a 1aa ", false, "SW1A 1AA", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePostalCode(tt.country, tt.postalCode, tt.keepZIP4)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePostalCode(%q, %q, %v) error = %v, want error %v", tt.country, tt.postalCode, tt.keepZIP4, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePostalCode(%q, %q, %v) = %q, want %q", tt.country, tt.postalCode, tt.keepZIP4, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamePostalCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		country  string
+		current  string
+		incoming string
+		keepZIP4 bool
+		want     bool
+	}{
+		{"same ZIP", "US", "43215", "43215", false, true},
+		{"ZIP+4 ignored", "US", "43215", "43215-1234", false, true},
+		{"ZIP+4 ignored both sides", "US", "43215-9999", "43215-1234", false, true},
+		{"ZIP+4 kept and differs", "US", "43215-9999", "43215-1234", true, false},
+		{"ZIP+4 kept, missing in Mike Albert", "US", "43215", "43215-1234", true, false},
+		{"ZIP+4 kept, incoming has none", "US", "43215-9999", "43215", true, true},
+		{"different ZIP", "US", "43215", "43216", false, false},
+		{"leading zero", "US", "02134", "02134-0001", false, true},
+		{"CA spacing and case", "CA", "k1a0b1", "K1A 0B1", false, true},
+		{"CA different", "CA", "K1A 0B1", "K1A 0B2", false, false},
+		{"invalid compared as text", "US", "N/A", "n/a", false, true},
+		{"invalid against valid", "US", "", "43215", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SamePostalCode(tt.country, tt.current, tt.incoming, tt.keepZIP4); got != tt.want {
+				t.Errorf("SamePostalCode(%q, %q, %q, %v) = %v, want %v", tt.country, tt.current, tt.incoming, tt.keepZIP4, got, tt.want)
+			}
+		})
+	}
+}
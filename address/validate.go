@@ -0,0 +1,199 @@
+package address
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Results of validating a postal code against the state and city of an address
+const (
+	Valid      = "valid"      // the postal code is in the state, and city when checked
+	Suspicious = "suspicious" // the postal code could not be confirmed, it is unassigned or the city does not match
+	Invalid    = "invalid"    // the postal code is not in the state, the combination is impossible
+	Unchecked  = "unchecked"  // no reference data for the country
+)
+
+// Validation is the result of validating an address's postal code, state and city
+type Validation struct {
+	Result string
+	Reason string // why the address is suspicious or invalid
+}
+
+//go:embed zipstate.csv
+var zipStateData string
+
+var (
+	zipStatesOnce sync.Once
+	zip3States    map[string][]string // by 3 digit ZIP prefix
+	zip5States    map[string][]string // by 5 digit ZIP code, exceptions to their prefix
+)
+
+// loadZIPStates parses the embedded ZIP code to state reference
+func loadZIPStates() {
+	zip3States = make(map[string][]string)
+	zip5States = make(map[string][]string)
+
+	r := csv.NewReader(strings.NewReader(zipStateData))
+	r.FieldsPerRecord = 3
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("embedded ZIP code reference: %+v", err))
+	}
+
+	for _, record := range records {
+		from, to, states := record[0], record[1], strings.Fields(record[2])
+		if len(from) == 5 {
+			zip5States[from] = states
+			continue
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || len(from) != 3 || len(to) != 3 {
+			panic(fmt.Sprintf("embedded ZIP code reference: invalid range %s-%s", from, to))
+		}
+		for prefix := start; prefix <= end; prefix++ {
+			zip3States[fmt.Sprintf("%03d", prefix)] = states
+		}
+	}
+}
+
+// ZIPStates returns the states a US ZIP code is in, nothing when its prefix is unassigned
+func ZIPStates(zip string) []string {
+	zipStatesOnce.Do(loadZIPStates)
+
+	zip = digitsOnly(zip)
+	if len(zip) < 5 {
+		return nil
+	}
+	if states, ok := zip5States[zip[:5]]; ok {
+		return states
+	}
+	return zip3States[zip[:3]]
+}
+
+// canadianProvinces are the provinces and territories by the first letter of the postal code
+var canadianProvinces = map[byte][]string{
+	'A': {"NL"}, 'B': {"NS"}, 'C': {"PE"}, 'E': {"NB"}, 'G': {"QC"}, 'H': {"QC"}, 'J': {"QC"},
+	'K': {"ON"}, 'L': {"ON"}, 'M': {"ON"}, 'N': {"ON"}, 'P': {"ON"}, 'R': {"MB"}, 'S': {"SK"},
+	'T': {"AB"}, 'V': {"BC"}, 'X': {"NT", "NU"}, 'Y': {"YT"},
+}
+
+// CityReference lists the cities of each postal code, by normalized postal code
+type CityReference map[string][]string
+
+// LoadCityReference reads a city reference file of postal code, city pairs, one pair per line in
+// CSV format, with an optional third state column that is ignored. A header line is skipped when
+// present. Postal codes may be listed on several lines for several cities.
+func LoadCityReference(file string) (CityReference, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	cities := make(CityReference)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		if len(record) < 2 {
+			err = fmt.Errorf("%s line %d: postal code and city are required", file, line)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+
+		postalCode, city := referenceKey(record[0]), NormalizeCity(record[1])
+		if line == 1 && len(postalCode) == 0 {
+			continue
+		}
+		if len(postalCode) == 0 || len(city) == 0 {
+			err = fmt.Errorf("%s line %d: a US ZIP code or Canadian postal code and city are required", file, line)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		cities[postalCode] = append(cities[postalCode], city)
+	}
+
+	return cities, nil
+}
+
+// referenceKey returns a city reference postal code in the form Validate looks it up by, a 5 digit
+// US ZIP code or a Canadian postal code as A1A 1A1, blank when it is neither
+func referenceKey(postalCode string) string {
+	compact := strings.Map(dropSeparators, strings.ToUpper(strings.TrimSpace(postalCode)))
+	if isCanadianPostalCode(compact) {
+		return compact[:3] + " " + compact[3:]
+	}
+	digits := digitsOnly(compact)
+	if len(digits) != len(compact) || (len(digits) != 5 && len(digits) != 9) {
+		return ""
+	}
+	return digits[:5]
+}
+
+// contains checks if values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks an address's postal code against its state, using the embedded ZIP code
+// reference for the US and the postal code's first letter for Canada, and against its city when a
+// city reference is given. It works entirely offline.
+func Validate(country, postalCode, state, city string, cities CityReference) Validation {
+	normalized, err := NormalizePostalCode(country, postalCode, false)
+	if err != nil {
+		return Validation{Result: Invalid, Reason: err.Error()}
+	}
+	state = NormalizeState(state)
+
+	var states []string
+	switch Country(country) {
+	case CountryUS:
+		states = ZIPStates(normalized)
+		if len(states) == 0 {
+			return Validation{Result: Suspicious, Reason: fmt.Sprintf("ZIP code %s is not assigned to any state", normalized)}
+		}
+	case CountryCA:
+		states = canadianProvinces[normalized[0]]
+		if len(states) == 0 {
+			return Validation{Result: Invalid, Reason: fmt.Sprintf("postal code %s is not assigned to any province", normalized)}
+		}
+	default:
+		return Validation{Result: Unchecked}
+	}
+
+	if !contains(states, state) {
+		return Validation{Result: Invalid, Reason: fmt.Sprintf("postal code %s is in %s, not %s", normalized, strings.Join(states, " or "), state)}
+	}
+
+	if known, ok := cities[normalized]; ok && !contains(known, NormalizeCity(city)) {
+		return Validation{Result: Suspicious, Reason: fmt.Sprintf("postal code %s is in %s, not %s", normalized, strings.Join(known, " or "), NormalizeCity(city))}
+	}
+
+	return Validation{Result: Valid}
+}
@@ -0,0 +1,89 @@
+package address
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestZIPStates(t *testing.T) {
+	tests := []struct {
+		zip  string
+		want []string
+	}{
+		{"43215", []string{"OH"}},
+		{"43215-1234", []string{"OH"}},
+		{"02134", []string{"MA"}},
+		{"00501", []string{"NY"}},
+		{"96799", []string{"AS"}},
+		{"42223", []string{"KY", "TN"}},
+		{"00001", nil},
+		{"2134", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ZIPStates(tt.zip); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ZIPStates(%q) = %v, want %v", tt.zip, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cities := CityReference{
+		"43215":   {"COLUMBUS"},
+		"K1A 0B1": {"OTTAWA"},
+	}
+
+	tests := []struct {
+		name                             string
+		country, postalCode, state, city string
+		want                             string
+	}{
+		{"valid", "US", "43215", "OH", "Columbus", Valid},
+		{"valid ZIP+4", "US", "43215-1234", "oh", "Columbus", Valid},
+		{"valid leading zero", "US", "02134", "MA", "Boston", Valid},
+		{"valid without city reference entry", "US", "45202", "OH", "Cincinnati", Valid},
+		{"ZIP in another state", "US", "43215", "KY", "Columbus", Invalid},
+		{"ZIP shared by two states", "US", "42223", "TN", "Fort Campbell", Valid},
+		{"unassigned ZIP prefix", "US", "00001", "NY", "New York", Suspicious},
+		{"malformed ZIP", "US", "4321", "OH", "Columbus", Invalid},
+		{"city does not match", "US", "43215", "OH", "Cleveland", Suspicious},
+		{"city with different spelling", "US", "43215", "OH", "columbus.", Valid},
+		{"Canadian postal code", "CA", "k1a0b1", "ON", "Ottawa", Valid},
+		{"Canadian postal code in another province", "CA", "K1A 0B1", "QC", "Ottawa", Invalid},
+		{"Canadian territories share a letter", "CA", "X0A 0H0", "NU", "Iqaluit", Valid},
+		{"Canadian city does not match", "CA", "K1A 0B1", "ON", "Toronto", Suspicious},
+		{"malformed Canadian postal code", "CA", "K1A 0B", "ON", "Ottawa", Invalid},
+		{"other country unchecked", "GB", "SW1A 1AA", "", "London", Unchecked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate(tt.country, tt.postalCode, tt.state, tt.city, cities)
+			if got.Result != tt.want {
+				t.Errorf("Validate(%q, %q, %q, %q) = %s (%s), want %s", tt.country, tt.postalCode, tt.state, tt.city, got.Result, got.Reason, tt.want)
+			}
+			if got.Result != Valid && got.Result != Unchecked && len(got.Reason) == 0 {
+				t.Errorf("Validate(%q, %q, %q, %q) = %s with no reason", tt.country, tt.postalCode, tt.state, tt.city, got.Result)
+			}
+		})
+	}
+}
+
+func TestLoadCityReference(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cities.csv")
+	data := "PostalCode,City,State\n43215,Columbus,OH\n43215-1234,Cols,OH\nk1a0b1,Ottawa\n"
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cities, err := LoadCityReference(file)
+	if err != nil {
+		t.Fatalf("LoadCityReference() error = %v", err)
+	}
+	want := CityReference{"43215": {"COLUMBUS", "COLS"}, "K1A 0B1": {"OTTAWA"}}
+	if !reflect.DeepEqual(cities, want) {
+		t.Errorf("LoadCityReference() = %v, want %v", cities, want)
+	}
+}
@@ -0,0 +1,72 @@
+package adp
+
+import (
+	"strings"
+)
+
+// ADP addresses a driver's address can be taken from
+const (
+	AddressLegal        = "legal"        // person.legalAddress
+	AddressMailing      = "mailing"      // person.mailingAddress
+	AddressOther        = "other"        // the first usable person.otherPersonalAddresses
+	AddressWorkLocation = "worklocation" // the primary work assignment's assigned work location
+)
+
+// AddressRules choose which ADP address is synced
+type AddressRules struct {
+	Sources    []string                                    // in order of preference, the first with a usable address is synced
+	Garageable func(line1, line2, city, state string) bool // when set, pass over addresses a vehicle can't be garaged at, such as PO boxes, to the next source
+}
+
+// usable checks if an address has at least a street line and postal code
+func usable(a ADPAddress) bool {
+	return len(strings.TrimSpace(a.LineOne)) > 0 && len(strings.TrimSpace(a.PostalCode)) > 0
+}
+
+// garageable checks if a vehicle could be garaged at an address, which rules out PO boxes and other mail-only addresses
+func (r AddressRules) garageable(a ADPAddress) bool {
+	return r.Garageable(a.LineOne, a.LineTwo, a.CityName, a.CountrySubdivisionLevel1.CodeValue)
+}
+
+// candidates returns the worker's addresses from source
+func candidates(source string, worker ADPWorker, assignment ADPWorkAssignment) []ADPAddress {
+	switch source {
+	case AddressLegal:
+		return []ADPAddress{worker.Person.LegalAddress}
+	case AddressMailing:
+		return []ADPAddress{worker.Person.MailingAddress}
+	case AddressOther:
+		return worker.Person.OtherPersonalAddresses
+	case AddressWorkLocation:
+		var addresses []ADPAddress
+		for _, l := range assignment.AssignedWorkLocations {
+			addresses = append(addresses, l.Address)
+		}
+		return addresses
+	}
+	return nil
+}
+
+// address returns the worker's address from the first source with a usable one, and the source.
+// With Garageable set, a garageable address is preferred over an earlier mail-only one. When no
+// source has a usable address the legal address is returned as is so it is reported as incomplete.
+func (r AddressRules) address(worker ADPWorker, assignment ADPWorkAssignment) (ADPAddress, string) {
+	if r.Garageable != nil {
+		for _, source := range r.Sources {
+			for _, a := range candidates(source, worker, assignment) {
+				if usable(a) && r.garageable(a) {
+					return a, source
+				}
+			}
+		}
+	}
+
+	for _, source := range r.Sources {
+		for _, a := range candidates(source, worker, assignment) {
+			if usable(a) {
+				return a, source
+			}
+		}
+	}
+	return worker.Person.LegalAddress, AddressLegal
+}
@@ -14,14 +14,25 @@ import (
 )
 
 type DriverHomeAddress struct {
-	EmployeeNumber string
-	LastName       string
-	FirstName      string
-	Address1       string
-	Address2       string
-	City           string
-	State          string
-	ZIPCode        string
+	EmployeeNumber     string
+	CompanyCode        string
+	WorkerID           string
+	AssociateOID       string
+	LastName           string // legal name
+	FirstName          string
+	PreferredLastName  string // preferred name, blank when the worker has none
+	PreferredFirstName string
+	Address1           string
+	Address2           string
+	City               string
+	State              string
+	ZIPCode            string
+	Country            string
+	AddressSource      string // ADP address the address came from
+	Email              string // personal email address
+	MobilePhone        string
+	HomePhone          string // landline
+	FleetDriver        bool   // marked as a fleet driver by the fleet driver custom field
 }
 
 // OAuth2Token represents an OAuth2 access token
@@ -44,12 +55,21 @@ type ADPWorkerID struct {
 
 // ADPWorker represents a worker from ADP Workforce Now
 type ADPWorker struct {
+	AssociateOID     string              `json:"associateOID"`
 	WorkerID         ADPWorkerID         `json:"workerId"`
 	Person           ADPPerson           `json:"person"`
+	WorkerDates      ADPWorkerDates      `json:"workerDates"`
 	WorkAssignments  []ADPWorkAssignment `json:"workAssignments"`
 	CustomFieldGroup ADPCustomFieldGroup `json:"customFieldGroup"`
 }
 
+// ADPWorkerDates represents a worker's employment dates in ADP
+type ADPWorkerDates struct {
+	OriginalHireDate string `json:"originalHireDate,omitempty"`
+	RehireDate       string `json:"rehireDate,omitempty"`
+	TerminationDate  string `json:"terminationDate,omitempty"`
+}
+
 // ADPCustomFieldGroup contains custom fields defined in ADP
 type ADPCustomFieldGroup struct {
 	StringFields []ADPCustomStringField `json:"stringFields"`
@@ -76,8 +96,13 @@ type ADPNameCode struct {
 
 // ADPPerson contains personal information
 type ADPPerson struct {
-	LegalName    ADPName         `json:"legalName"`
-	LegalAddress ADPLegalAddress `json:"legalAddress"`
+	LegalName     ADPName    `json:"legalName"`
+	PreferredName ADPName    `json:"preferredName"`
+	LegalAddress  ADPAddress `json:"legalAddress"`
+	// MailingAddress and OtherPersonalAddresses are alternative address sources, see AddressRules
+	MailingAddress         ADPAddress       `json:"mailingAddress"`
+	OtherPersonalAddresses []ADPAddress     `json:"otherPersonalAddresses,omitempty"`
+	Communication          ADPCommunication `json:"communication"`
 }
 
 // ADPName contains name information
@@ -86,13 +111,14 @@ type ADPName struct {
 	FamilyName1 string `json:"familyName1"`
 }
 
-// ADPLegalAddress contains the home address from person.legalAddress
-type ADPLegalAddress struct {
+// ADPAddress contains a postal address, such as the home address from person.legalAddress
+type ADPAddress struct {
 	LineOne                  string           `json:"lineOne"`
 	LineTwo                  string           `json:"lineTwo,omitempty"`
 	CityName                 string           `json:"cityName"`
 	CountrySubdivisionLevel1 ADPCountrySubdiv `json:"countrySubdivisionLevel1"`
 	PostalCode               string           `json:"postalCode"`
+	CountryCode              string           `json:"countryCode"`
 }
 
 // ADPCountrySubdiv contains state/region info
@@ -116,9 +142,20 @@ type ADPStatusCode struct {
 type ADPWorkAssignment struct {
 	ItemID            string              `json:"itemID"`
 	PayrollFileNumber string              `json:"payrollFileNumber"`
+	PayrollGroupCode  string              `json:"payrollGroupCode"`
 	PrimaryIndicator  bool                `json:"primaryIndicator"`
 	AssignmentStatus  ADPAssignmentStatus `json:"assignmentStatus"`
 	CustomFieldGroup  ADPCustomFieldGroup `json:"customFieldGroup"`
+	HireDate          string              `json:"hireDate,omitempty"`
+	TerminationDate   string              `json:"terminationDate,omitempty"`
+
+	AssignedWorkLocations []ADPWorkLocation `json:"assignedWorkLocations,omitempty"`
+}
+
+// ADPWorkLocation is a location a work assignment is based at
+type ADPWorkLocation struct {
+	NameCode ADPNameCode `json:"nameCode"`
+	Address  ADPAddress  `json:"address"`
 }
 
 // Client represents the ADP API client
@@ -271,112 +308,266 @@ func (c *Client) GetWorkers(ctx context.Context) ([]ADPWorker, error) {
 	return allWorkers, nil
 }
 
-// isOverdriveSyncField checks if a field name matches "OVERDRIVE SYNC" by checking
-// both codeValue and shortName, and also checks for partial/contains matches.
-func isOverdriveSyncField(nameCode ADPNameCode) bool {
-	for _, name := range []string{nameCode.CodeValue, nameCode.ShortName} {
-		normalized := strings.ToUpper(strings.TrimSpace(name))
-		if normalized == "OVERDRIVE SYNC" ||
-			normalized == "OVERDRIVE_SYNC" ||
-			normalized == "OVERDRIVESYNC" ||
-			strings.Contains(normalized, "OVERDRIVE") {
+// Match modes for custom field names
+const (
+	MatchExact      = "exact"      // name equals a configured field name
+	MatchNormalized = "normalized" // name equals a configured field name ignoring case, spaces and punctuation
+	MatchContains   = "contains"   // name contains a configured field name ignoring case
+)
+
+// Sources for the employee number used to find a worker in Mike Albert
+const (
+	KeyPayrollFileNumber = "payrollFileNumber" // primary work assignment payroll file number
+	KeyWorkerID          = "workerId"          // workerID.idValue
+	KeyAssociateOID      = "associateOID"      // associate OID
+	KeyCustomField       = "custom"            // a custom field, worker level or work assignment level
+)
+
+// What the sync does with a worker by primary work assignment status
+const (
+	StatusSync      = "sync"      // sync the worker's address
+	StatusSkip      = "skip"      // leave the worker out of the sync
+	StatusTerminate = "terminate" // treat the worker as terminated
+	StatusHold      = "hold"      // leave the worker out of the sync and hold them for review
+)
+
+// Rules control which ADP workers are eligible for the sync
+type Rules struct {
+	OptOut         OptOutRules
+	EmployeeNumber EmployeeNumberRules
+	FleetDriver    FleetDriverRules
+	Statuses       StatusRules
+	Address        AddressRules
+	Contact        ContactRules
+}
+
+// StatusRules map ADP assignment status codes to what the sync does with the worker
+type StatusRules struct {
+	Actions map[string]string // by status code, e.g. "A": StatusSync, "L": StatusSync, "T": StatusTerminate
+	Default string            // action for status codes not in Actions
+}
+
+// Action returns what the sync does with a worker with assignment status code
+func (r StatusRules) Action(code string) string {
+	if action, ok := r.Actions[strings.ToUpper(code)]; ok {
+		return action
+	}
+	if len(r.Default) > 0 {
+		return r.Default
+	}
+	return StatusSkip
+}
+
+// FleetDriverRules describe the custom field marking a worker as a fleet driver, used to onboard
+// new hires into Mike Albert
+type FleetDriverRules struct {
+	FieldNames []string // custom field codes or short names, e.g. "FLEET DRIVER"
+	Values     []string // values marking the worker as a fleet driver, e.g. "Yes"
+}
+
+// isFleetDriver checks the fleet driver custom field, returning its value and whether it marks the worker as a fleet driver
+func (r FleetDriverRules) isFleetDriver(worker ADPWorker) (string, bool) {
+	if len(r.FieldNames) == 0 {
+		return "", false
+	}
+	value := fieldMatcher{Names: r.FieldNames, Mode: MatchNormalized}.value(worker)
+	return value, len(value) > 0 && containsFold(r.Values, value)
+}
+
+// EmployeeNumberRules describe where a worker's employee number comes from
+type EmployeeNumberRules struct {
+	Source      string // one of KeyPayrollFileNumber (default), KeyWorkerID, KeyAssociateOID or KeyCustomField
+	CustomField string // custom field code or short name when Source is KeyCustomField
+}
+
+// employeeNumber returns the worker's employee number from the configured source
+func (r EmployeeNumberRules) employeeNumber(worker ADPWorker, primaryAssignment ADPWorkAssignment) string {
+	switch r.Source {
+	case KeyWorkerID:
+		return strings.TrimSpace(worker.WorkerID.IDValue)
+	case KeyAssociateOID:
+		return strings.TrimSpace(worker.AssociateOID)
+	case KeyCustomField:
+		return fieldMatcher{Names: []string{r.CustomField}, Mode: MatchNormalized}.value(worker)
+	}
+	return strings.TrimSpace(primaryAssignment.PayrollFileNumber)
+}
+
+// OptOutRules describe the custom field workers use to opt out of the sync
+type OptOutRules struct {
+	FieldNames   []string // custom field codes or short names, e.g. "OVERDRIVE SYNC"
+	MatchMode    string   // one of MatchExact, MatchNormalized or MatchContains
+	OptOutValues []string // values that exclude the worker, e.g. "No"
+	OptInValues  []string // values that explicitly include the worker, blank always includes
+}
+
+// matcher returns the matcher for the opt-out custom field
+func (r OptOutRules) matcher() fieldMatcher {
+	return fieldMatcher{Names: r.FieldNames, Mode: r.MatchMode}
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
 			return true
 		}
 	}
 	return false
 }
 
-// searchCustomFieldGroup looks for the OVERDRIVE SYNC value in a CustomFieldGroup.
-func searchCustomFieldGroup(cfg ADPCustomFieldGroup) (string, bool) {
-	for _, field := range cfg.StringFields {
-		if isOverdriveSyncField(field.NameCode) {
-			return strings.TrimSpace(field.StringValue), true
-		}
+// getOptOutValue returns the value of the opt-out custom field for a worker.
+// The first matching field wins; a warning is logged when other matching fields disagree with it.
+// Returns "" (blank) if the field is not present or has no value.
+func (r OptOutRules) getOptOutValue(worker ADPWorker, employeeNumber string) string {
+	fields := r.matcher().find(worker)
+	if len(fields) == 0 {
+		return ""
 	}
-	for _, field := range cfg.CodeFields {
-		if isOverdriveSyncField(field.NameCode) {
-			return strings.TrimSpace(field.CodeValue), true
+
+	for _, f := range fields[1:] {
+		if !strings.EqualFold(f.Value, fields[0].Value) {
+			log.Printf("WARN: employee %s has conflicting opt-out fields, using %s='%s' and ignoring %s='%s'",
+				employeeNumber, fields[0].Name, fields[0].Value, f.Name, f.Value)
 		}
 	}
-	return "", false
+
+	return fields[0].Value
 }
 
-// getOverdriveSyncValue returns the value of the "OVERDRIVE SYNC" custom field for a worker.
-// It searches at the worker level and at each work assignment level.
-// Returns "" (blank) if the field is not present or has no value.
-func getOverdriveSyncValue(worker ADPWorker) string {
-	// 1) Check worker-level custom fields
-	if val, found := searchCustomFieldGroup(worker.CustomFieldGroup); found {
-		return val
+// isOptedOut checks an opt-out field value against the configured opt-out and opt-in values.
+// Values that are neither are treated as opt-in, with a warning.
+func (r OptOutRules) isOptedOut(value, employeeNumber string) bool {
+	if len(value) == 0 {
+		return false
 	}
-	// 2) Check work assignment-level custom fields
-	for _, wa := range worker.WorkAssignments {
-		if val, found := searchCustomFieldGroup(wa.CustomFieldGroup); found {
-			return val
-		}
+	if containsFold(r.OptOutValues, value) {
+		return true
+	}
+	if len(r.OptInValues) > 0 && !containsFold(r.OptInValues, value) {
+		log.Printf("WARN: employee %s has unrecognized opt-out field value '%s', treating as opt-in", employeeNumber, value)
 	}
-	return ""
+	return false
 }
 
-// GetDriverHomeAddresses gets the driver home addresses from ADP Workforce Now
-func (c *Client) GetDriverHomeAddresses() ([]DriverHomeAddress, error) {
+// evaluateWorker applies the eligibility rules to a single worker, returning the decision and,
+// when eligible, the driver home address
+func (rules Rules) evaluateWorker(worker ADPWorker) (Decision, *DriverHomeAddress) {
+	decision := Decision{
+		WorkerID:     worker.WorkerID.IDValue,
+		AssociateOID: worker.AssociateOID,
+		Name:         strings.TrimSpace(worker.Person.LegalName.GivenName + " " + worker.Person.LegalName.FamilyName1),
+		FirstName:    strings.TrimSpace(worker.Person.LegalName.GivenName),
+		LastName:     strings.TrimSpace(worker.Person.LegalName.FamilyName1),
+	}
+
+	// Find the primary work assignment to get the employee number
+	decision.inspect("workAssignments", fmt.Sprintf("%d", len(worker.WorkAssignments)))
+	if len(worker.WorkAssignments) == 0 {
+		return decision.exclude(RuleNoAssignments), nil
+	}
+
+	// Use the configured source as the employee number, by default payrollFileNumber from the primary (first) work assignment
+	primaryAssignment := worker.WorkAssignments[0]
+	employeeNumber := rules.EmployeeNumber.employeeNumber(worker, primaryAssignment)
+	decision.EmployeeNumber = employeeNumber
+	decision.CompanyCode = primaryAssignment.PayrollGroupCode
+	decision.TerminationDate = primaryAssignment.TerminationDate
+	if len(decision.TerminationDate) == 0 {
+		decision.TerminationDate = worker.WorkerDates.TerminationDate
+	}
+	decision.RehireDate = worker.WorkerDates.RehireDate
+
+	// Only sync workers whose assignment status is configured to sync, by default "A" = Active
+	statusCode := strings.ToUpper(primaryAssignment.AssignmentStatus.StatusCode.CodeValue)
+	decision.Status = statusCode
+	decision.StatusAction = rules.Statuses.Action(statusCode)
+	decision.inspect("assignmentStatus", statusCode)
+	decision.inspect("statusAction", decision.StatusAction)
+	switch decision.StatusAction {
+	case StatusSync:
+	case StatusTerminate:
+		return decision.exclude(RuleTerminated), nil
+	case StatusHold:
+		return decision.exclude(RuleHeld), nil
+	default:
+		return decision.exclude(RuleInactive), nil
+	}
+
+	// Workers without an employee number cannot be found in Mike Albert
+	decision.inspect("employeeNumber", employeeNumber)
+	if employeeNumber == "" {
+		return decision.exclude(RuleNoEmployeeNumber), nil
+	}
+
+	// Check the opt-out custom field (e.g. OVERDRIVE SYNC)
+	// opt-out value (e.g. "No") = do NOT sync, Blank = OK to sync
+	optOutValue := rules.OptOut.getOptOutValue(worker, employeeNumber)
+	decision.inspect("optOutValue", optOutValue)
+	if rules.OptOut.isOptedOut(optOutValue, employeeNumber) {
+		return decision.exclude(RuleOptedOut), nil
+	}
+
+	// Fleet drivers can be onboarded into mike albert when not found there
+	fleetDriverValue, fleetDriver := rules.FleetDriver.isFleetDriver(worker)
+	if len(rules.FleetDriver.FieldNames) > 0 {
+		decision.inspect("fleetDriver", fleetDriverValue)
+	}
+
+	// Get address from the first configured source that has one, by default person.legalAddress
+	address, source := rules.Address.address(worker, primaryAssignment)
+	decision.inspect("addressSource", source)
+
+	return decision.include(RuleEligible), &DriverHomeAddress{
+		EmployeeNumber:     employeeNumber,
+		CompanyCode:        primaryAssignment.PayrollGroupCode,
+		WorkerID:           worker.WorkerID.IDValue,
+		AssociateOID:       worker.AssociateOID,
+		LastName:           worker.Person.LegalName.FamilyName1,
+		FirstName:          worker.Person.LegalName.GivenName,
+		PreferredLastName:  worker.Person.PreferredName.FamilyName1,
+		PreferredFirstName: worker.Person.PreferredName.GivenName,
+		Address1:           address.LineOne,
+		Address2:           address.LineTwo,
+		City:               address.CityName,
+		State:              address.CountrySubdivisionLevel1.CodeValue,
+		ZIPCode:            address.PostalCode,
+		Country:            address.CountryCode,
+		AddressSource:      source,
+		Email:              worker.Person.Communication.email(rules.Contact.UnmarkedEmail),
+		MobilePhone:        phone(worker.Person.Communication.Mobiles),
+		HomePhone:          phone(worker.Person.Communication.Landlines),
+		FleetDriver:        fleetDriver,
+	}
+}
+
+// GetDriverHomeAddresses gets the driver home addresses from ADP Workforce Now, along with the
+// eligibility decision made for every worker
+func (c *Client) GetDriverHomeAddresses(rules Rules) ([]DriverHomeAddress, []Decision, error) {
 	ctx := context.Background()
 
 	workers, err := c.GetWorkers(ctx)
 	if err != nil {
 		log.Printf("%+v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	var driverHomeAddresses []DriverHomeAddress
-	skippedInactive := 0
-	skippedOverdrive := 0
+	decisions := make([]Decision, 0, len(workers))
+	counts := make(map[string]int)
 
 	for _, worker := range workers {
-		// Find the primary work assignment to get the payrollFileNumber
-		if len(worker.WorkAssignments) == 0 {
-			continue
-		}
-
-		// Skip terminated/inactive workers - only sync workers with active assignments
-		primaryAssignment := worker.WorkAssignments[0]
-		statusCode := strings.ToUpper(primaryAssignment.AssignmentStatus.StatusCode.CodeValue)
-		if statusCode != "A" { // "A" = Active
-			skippedInactive++
-			continue
-		}
+		decision, driverHomeAddress := rules.evaluateWorker(worker)
+		decisions = append(decisions, decision)
+		counts[decision.Rule]++
 
-		// Use payrollFileNumber from the primary (first) work assignment as the employee number
-		employeeNumber := primaryAssignment.PayrollFileNumber
-		if employeeNumber == "" {
-			continue
+		if driverHomeAddress != nil {
+			driverHomeAddresses = append(driverHomeAddresses, *driverHomeAddress)
 		}
-
-		// Check the OVERDRIVE SYNC custom field
-		// "No" = do NOT sync, Blank = OK to sync
-		overdriveSyncValue := getOverdriveSyncValue(worker)
-		if strings.EqualFold(overdriveSyncValue, "No") {
-			skippedOverdrive++
-			continue
-		}
-
-		// Get address from person.legalAddress
-		address := worker.Person.LegalAddress
-
-		driverHomeAddresses = append(driverHomeAddresses, DriverHomeAddress{
-			EmployeeNumber: employeeNumber,
-			LastName:       worker.Person.LegalName.FamilyName1,
-			FirstName:      worker.Person.LegalName.GivenName,
-			Address1:       address.LineOne,
-			Address2:       address.LineTwo,
-			City:           address.CityName,
-			State:          address.CountrySubdivisionLevel1.CodeValue,
-			ZIPCode:        address.PostalCode,
-		})
 	}
 
-	log.Printf("ADP filter results: %d total workers, %d skipped (inactive/terminated), %d skipped (OVERDRIVE SYNC=No), %d eligible for sync",
-		len(workers), skippedInactive, skippedOverdrive, len(driverHomeAddresses))
+	log.Printf("ADP filter results: %d total workers, %d skipped (no assignments), %d skipped (status), %d skipped (terminated), %d held (status), %d skipped (no employee number), %d skipped (opted out), %d eligible for sync",
+		len(workers), counts[RuleNoAssignments], counts[RuleInactive], counts[RuleTerminated], counts[RuleHeld], counts[RuleNoEmployeeNumber], counts[RuleOptedOut], len(driverHomeAddresses))
 
-	return driverHomeAddresses, nil
+	return driverHomeAddresses, decisions, nil
 }
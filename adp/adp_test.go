@@ -0,0 +1,32 @@
+package adp
+
+import "testing"
+
+func TestStatusRulesAction(t *testing.T) {
+	rules := StatusRules{Actions: map[string]string{"A": StatusSync, "L": StatusSync, "T": StatusTerminate, "S": StatusHold}, Default: StatusSkip}
+
+	tests := []struct {
+		name   string
+		rules  StatusRules
+		code   string
+		action string
+	}{
+		{"active", rules, "A", StatusSync},
+		{"leave", rules, "L", StatusSync},
+		{"terminated", rules, "T", StatusTerminate},
+		{"held", rules, "S", StatusHold},
+		{"lowercase code", rules, "l", StatusSync},
+		{"unlisted code takes the default", rules, "R", StatusSkip},
+		{"blank code takes the default", rules, "", StatusSkip},
+		{"configured default", StatusRules{Actions: rules.Actions, Default: StatusHold}, "R", StatusHold},
+		{"no default skips", StatusRules{Actions: rules.Actions}, "R", StatusSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Action(tt.code); got != tt.action {
+				t.Errorf("Action(%q) = %s, want %s", tt.code, got, tt.action)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package adp
+
+import "strings"
+
+// ADPCommunication contains the contact details from person.communication
+type ADPCommunication struct {
+	Emails    []ADPEmail `json:"emails,omitempty"`
+	Mobiles   []ADPPhone `json:"mobiles,omitempty"`
+	Landlines []ADPPhone `json:"landlines,omitempty"`
+}
+
+// ContactRules control which ADP contact details are taken for a worker
+type ContactRules struct {
+	UnmarkedEmail bool // take the first email address when none is marked personal, it may be a work address
+}
+
+// ADPEmail is an email address
+type ADPEmail struct {
+	NameCode ADPNameCode `json:"nameCode"`
+	EmailURI string      `json:"emailUri"`
+}
+
+// ADPPhone is a mobile or landline phone number, either in parts or formatted
+type ADPPhone struct {
+	NameCode        ADPNameCode `json:"nameCode"`
+	CountryDialing  string      `json:"countryDialing,omitempty"`
+	AreaDialing     string      `json:"areaDialing,omitempty"`
+	DialNumber      string      `json:"dialNumber,omitempty"`
+	FormattedNumber string      `json:"formattedNumber,omitempty"`
+}
+
+// number returns the phone number, from its parts when ADP has them, otherwise as formatted
+func (p ADPPhone) number() string {
+	if len(p.AreaDialing) > 0 && len(p.DialNumber) > 0 {
+		number := p.AreaDialing + p.DialNumber
+		if len(p.CountryDialing) > 0 {
+			number = "+" + strings.TrimPrefix(p.CountryDialing, "+") + number
+		}
+		return number
+	}
+	return strings.TrimSpace(p.FormattedNumber)
+}
+
+// isPersonal checks if a name code marks a personal rather than work contact
+func isPersonal(nameCode ADPNameCode) bool {
+	return strings.Contains(strings.ToUpper(nameCode.CodeValue+" "+nameCode.ShortName), "PERSONAL")
+}
+
+// email returns the worker's personal email address. When none is marked personal it is blank, or
+// the first address when unmarked is set.
+func (c ADPCommunication) email(unmarked bool) string {
+	for _, e := range c.Emails {
+		if isPersonal(e.NameCode) {
+			return strings.TrimSpace(e.EmailURI)
+		}
+	}
+	if unmarked && len(c.Emails) > 0 {
+		return strings.TrimSpace(c.Emails[0].EmailURI)
+	}
+	return ""
+}
+
+// phone returns the personal phone number, or the first one when none is marked personal
+func phone(phones []ADPPhone) string {
+	for _, p := range phones {
+		if isPersonal(p.NameCode) {
+			return p.number()
+		}
+	}
+	if len(phones) > 0 {
+		return phones[0].number()
+	}
+	return ""
+}
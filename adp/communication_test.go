@@ -0,0 +1,28 @@
+package adp
+
+import "testing"
+
+func TestEmail(t *testing.T) {
+	personal := ADPEmail{NameCode: ADPNameCode{CodeValue: "Personal E-mail"}, EmailURI: " jane@example.com "}
+	work := ADPEmail{NameCode: ADPNameCode{CodeValue: "Work E-mail"}, EmailURI: "jane.smith@company.com"}
+
+	tests := []struct {
+		name     string
+		emails   []ADPEmail
+		unmarked bool
+		want     string
+	}{
+		{"personal preferred over work", []ADPEmail{work, personal}, false, "jane@example.com"},
+		{"work only", []ADPEmail{work}, false, ""},
+		{"work only with unmarked fallback", []ADPEmail{work}, true, "jane.smith@company.com"},
+		{"none", nil, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ADPCommunication{Emails: tt.emails}).email(tt.unmarked); got != tt.want {
+				t.Errorf("email(%v) = %q, want %q", tt.unmarked, got, tt.want)
+			}
+		})
+	}
+}
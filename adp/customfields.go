@@ -0,0 +1,101 @@
+package adp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// customField is a custom field value found on a worker
+type customField struct {
+	Name  string
+	Value string
+}
+
+// fieldMatcher finds custom fields by code or short name
+type fieldMatcher struct {
+	Names []string // custom field codes or short names
+	Mode  string   // one of MatchExact, MatchNormalized or MatchContains
+}
+
+// normalizeFieldName uppercases a field name and drops everything but letters and digits
+func normalizeFieldName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// matches checks if a custom field matches one of the names by checking both codeValue and
+// shortName using the match mode.
+func (m fieldMatcher) matches(nameCode ADPNameCode) bool {
+	for _, name := range []string{nameCode.CodeValue, nameCode.ShortName} {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		for _, want := range m.Names {
+			switch m.Mode {
+			case MatchExact:
+				if name == strings.TrimSpace(want) {
+					return true
+				}
+			case MatchContains:
+				if strings.Contains(strings.ToUpper(name), strings.ToUpper(strings.TrimSpace(want))) {
+					return true
+				}
+			default:
+				if normalizeFieldName(name) == normalizeFieldName(want) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// fieldName returns a printable name for a custom field
+func fieldName(nameCode ADPNameCode) string {
+	if len(nameCode.ShortName) > 0 {
+		return nameCode.ShortName
+	}
+	return nameCode.CodeValue
+}
+
+// search collects the matching field values in a CustomFieldGroup.
+func (m fieldMatcher) search(cfg ADPCustomFieldGroup) []customField {
+	var found []customField
+	for _, field := range cfg.StringFields {
+		if m.matches(field.NameCode) {
+			found = append(found, customField{Name: fieldName(field.NameCode), Value: strings.TrimSpace(field.StringValue)})
+		}
+	}
+	for _, field := range cfg.CodeFields {
+		if m.matches(field.NameCode) {
+			found = append(found, customField{Name: fieldName(field.NameCode), Value: strings.TrimSpace(field.CodeValue)})
+		}
+	}
+	return found
+}
+
+// find returns all matching custom fields for a worker, worker level first followed by each work
+// assignment in order.
+func (m fieldMatcher) find(worker ADPWorker) []customField {
+	found := m.search(worker.CustomFieldGroup)
+	for _, wa := range worker.WorkAssignments {
+		found = append(found, m.search(wa.CustomFieldGroup)...)
+	}
+	return found
+}
+
+// value returns the first matching custom field value for a worker, "" (blank) if not present
+func (m fieldMatcher) value(worker ADPWorker) string {
+	for _, f := range m.find(worker) {
+		if len(f.Value) > 0 {
+			return f.Value
+		}
+	}
+	return ""
+}
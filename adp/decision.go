@@ -0,0 +1,93 @@
+package adp
+
+import (
+	"strings"
+)
+
+// Eligibility rules that admit or exclude an ADP worker
+const (
+	RuleEligible         = "eligible"
+	RuleNoAssignments    = "no work assignments"
+	RuleInactive         = "assignment status skipped"
+	RuleTerminated       = "assignment terminated"
+	RuleHeld             = "assignment status held"
+	RuleNoEmployeeNumber = "blank employee number"
+	RuleOptedOut         = "opted out"
+)
+
+// Rules that exclude an eligible ADP worker while planning the sync
+const (
+	RuleIncompleteAddress = "incomplete or invalid address"
+	RuleNonGarageable     = "non-garageable address"
+	RuleNotFound          = "not found in Mike Albert"
+	RuleLinkNotFound      = "linked Mike Albert driver not found"
+	RuleMultipleMatches   = "several Mike Albert drivers, none selected"
+	RuleNameMismatch      = "name does not match Mike Albert"
+)
+
+// Decision records which rule admitted or excluded an ADP worker and the values inspected along the way
+type Decision struct {
+	WorkerID        string
+	AssociateOID    string
+	EmployeeNumber  string
+	CompanyCode     string
+	Name            string
+	FirstName       string // legal name, to verify drivers found for workers no longer eligible
+	LastName        string
+	Status          string // primary work assignment status code
+	StatusAction    string // what the sync does with the status code
+	TerminationDate string
+	RehireDate      string // from workerDates, set when the worker was rehired
+	Eligible        bool
+	Rule            string
+	Values          []string // inspected values as name=value, in the order they were checked
+}
+
+// inspect records a value the decision was based on
+func (d *Decision) inspect(name, value string) {
+	d.Values = append(d.Values, name+"="+value)
+}
+
+// include marks the worker as eligible under rule
+func (d Decision) include(rule string) Decision {
+	d.Eligible = true
+	d.Rule = rule
+	return d
+}
+
+// exclude marks the worker as excluded by rule
+func (d Decision) exclude(rule string) Decision {
+	d.Eligible = false
+	d.Rule = rule
+	return d
+}
+
+// Note records a value found while planning the sync for an eligible worker
+func (d *Decision) Note(name, value string) {
+	d.inspect(name, value)
+}
+
+// Exclude marks an eligible worker as excluded by a rule applied while planning the sync, recording
+// the value it was based on
+func (d *Decision) Exclude(rule, name, value string) {
+	d.inspect(name, value)
+	*d = d.exclude(rule)
+}
+
+// Detail returns the inspected values as a single line
+func (d Decision) Detail() string {
+	return strings.Join(d.Values, "; ")
+}
+
+// Matches checks if the decision is for the worker identified by id, which may be an employee
+// number (with or without leading zeros), worker ID or associate OID
+func (d Decision) Matches(id string) bool {
+	id = strings.TrimSpace(id)
+	if len(id) == 0 {
+		return false
+	}
+	if strings.EqualFold(d.WorkerID, id) || strings.EqualFold(d.AssociateOID, id) {
+		return true
+	}
+	return len(d.EmployeeNumber) > 0 && strings.TrimLeft(d.EmployeeNumber, "0") == strings.TrimLeft(id, "0")
+}
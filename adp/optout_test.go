@@ -0,0 +1,71 @@
+package adp
+
+import "testing"
+
+func TestOptOutFieldMatch(t *testing.T) {
+	field := func(code, short, value string) ADPWorker {
+		return ADPWorker{CustomFieldGroup: ADPCustomFieldGroup{StringFields: []ADPCustomStringField{
+			{NameCode: ADPNameCode{CodeValue: code, ShortName: short}, StringValue: value},
+		}}}
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		worker ADPWorker
+		want   string
+	}{
+		{"exact code", MatchExact, field("OVERDRIVE SYNC", "", "No"), "No"},
+		{"exact short name", MatchExact, field("C123", "OVERDRIVE SYNC", "No"), "No"},
+		{"exact is case sensitive", MatchExact, field("Overdrive Sync", "", "No"), ""},
+		{"normalized ignores case and punctuation", MatchNormalized, field("overdrive-sync", "", "No"), "No"},
+		{"normalized needs every letter", MatchNormalized, field("OVERDRIVE SYNC OPT", "", "No"), ""},
+		{"contains", MatchContains, field("EMP OVERDRIVE SYNC FLAG", "", "No"), "No"},
+		{"no custom field", MatchContains, ADPWorker{}, ""},
+		{"value trimmed", MatchNormalized, field("OVERDRIVE SYNC", "", " No "), "No"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := OptOutRules{FieldNames: []string{"OVERDRIVE SYNC"}, MatchMode: tt.mode}
+			if got := r.getOptOutValue(tt.worker, "1001"); got != tt.want {
+				t.Errorf("getOptOutValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptOutFieldOnAssignment(t *testing.T) {
+	worker := ADPWorker{WorkAssignments: []ADPWorkAssignment{{CustomFieldGroup: ADPCustomFieldGroup{CodeFields: []ADPCustomCodeField{
+		{NameCode: ADPNameCode{CodeValue: "OVERDRIVE SYNC"}, CodeValue: "No"},
+	}}}}}
+
+	r := OptOutRules{FieldNames: []string{"OVERDRIVE SYNC"}, MatchMode: MatchNormalized}
+	if got := r.getOptOutValue(worker, "1001"); got != "No" {
+		t.Errorf("getOptOutValue() = %q, want %q", got, "No")
+	}
+}
+
+func TestIsOptedOut(t *testing.T) {
+	r := OptOutRules{OptOutValues: []string{"No", " N "}, OptInValues: []string{"Yes"}}
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"No", true},
+		{"no", true},
+		{"N", true},
+		{"Yes", false},
+		{"", false},
+		{"Maybe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := r.isOptedOut(tt.value, "1001"); got != tt.want {
+				t.Errorf("isOptedOut(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
 	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/keymap"
 	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
 )
 
@@ -23,12 +23,23 @@ func main() {
 	// command line app
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "\nUsage of %s build %s\n", os.Args[0], buildnum)
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s -config <file> [command]\n\nCommands:\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "  sync                      sync driver addresses from ADP to Mike Albert (default)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  explain <employeeNumber>  show why an ADP worker is or is not synced\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  links list                list links between ADP workers and Mike Albert drivers\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  links add <oid> <driver>  link an ADP associate OID to a Mike Albert driver ID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  links remove <oid>        remove the link for an ADP associate OID\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  links auto                link ADP workers that match a single Mike Albert driver\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  review export <file>      write the items held for review to a CSV file\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  review import <file>      apply the decisions filled in to an exported review file\n\n")
 		flag.PrintDefaults()
 	}
 
 	// process command line
 	var configFile string
+	var force bool
 	flag.StringVar(&configFile, "config", "", "Configuration file")
+	flag.BoolVar(&force, "force", false, "Update drivers even when the mass-change guardrails are exceeded")
 	flag.Parse()
 
 	if len(configFile) == 0 {
@@ -50,93 +61,95 @@ func main() {
 		os.Exit(1)
 	}
 
-	// create mike albert client
-	mac, err := mikealbert.NewClient(config.MikeAlbert.ClientId, config.MikeAlbert.ClientSecret, config.MikeAlbert.Endpoint)
-	if err != nil {
-		log.Printf("%+v", err)
+	// run command, sync by default
+	switch flag.Arg(0) {
+	case "", "sync":
+		err = runSync(ac, force)
+	case "explain":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		err = runExplain(ac, flag.Arg(1))
+	case "links":
+		err = runLinks(ac, flag.Args()[1:])
+	case "review":
+		err = runReview(flag.Args()[1:])
+	default:
+		flag.Usage()
 		os.Exit(1)
 	}
-
-	// get employees from ADP
-	drivers, err := ac.GetDriverHomeAddresses()
 	if err != nil {
 		log.Printf("%+v", err)
 		os.Exit(1)
 	}
+}
 
-	log.Printf("Found %d drivers from ADP", len(drivers))
-
-	// sync each driver to mike albert
-	updated := 0
-	unchanged := 0
-	notFound := 0
-	skipped := 0
-	errors := 0
+// eligibilityRules builds the ADP eligibility rules from configuration
+func eligibilityRules() adp.Rules {
+	rules := adp.Rules{
+		OptOut: adp.OptOutRules{
+			FieldNames:   config.Adp.OptOut.Fields,
+			MatchMode:    config.Adp.OptOut.Match,
+			OptOutValues: config.Adp.OptOut.OptOutValues,
+			OptInValues:  config.Adp.OptOut.OptInValues,
+		},
+		EmployeeNumber: adp.EmployeeNumberRules{
+			Source:      config.EmployeeNumber.Source,
+			CustomField: config.EmployeeNumber.CustomField,
+		},
+		Statuses: adp.StatusRules{
+			Actions: config.Statuses.Actions,
+			Default: config.Statuses.Default,
+		},
+		Address: adp.AddressRules{
+			Sources: config.Address.Sources,
+		},
+		Contact: adp.ContactRules{
+			UnmarkedEmail: config.Contact.UnmarkedEmail,
+		},
+	}
+	if config.Address.NonGarageablePolicy == policyFallback {
+		rules.Address.Garageable = garageable
+	}
+	if config.Onboarding.Enabled {
+		rules.FleetDriver = adp.FleetDriverRules{
+			FieldNames: config.Onboarding.Fields,
+			Values:     config.Onboarding.Values,
+		}
+	}
+	return rules
+}
 
-	for _, d := range drivers {
-		// Mike Albert stores employee numbers without leading zeros
-		employeeNumber := strings.TrimLeft(d.EmployeeNumber, "0")
+// employeeNumberMapper builds the ADP to Mike Albert employee number mapping from configuration
+func employeeNumberMapper() (keymap.Mapper, error) {
+	m := keymap.Mapper{
+		StripZeros:      config.EmployeeNumber.StripZeros,
+		PadLength:       config.EmployeeNumber.PadLength,
+		CompanyPrefixes: config.EmployeeNumber.CompanyPrefixes,
+	}
 
-		// find the driver in mike albert by employee number
-		maDrivers, err := mac.FindDrivers(employeeNumber)
+	if len(config.EmployeeNumber.OverridesFile) > 0 {
+		overrides, err := keymap.LoadOverrides(config.EmployeeNumber.OverridesFile)
 		if err != nil {
-			log.Printf("ERROR finding driver %s in Mike Albert: %+v", employeeNumber, err)
-			errors++
-			continue
-		}
-
-		if len(maDrivers) == 0 {
-			notFound++
-			continue
+			log.Printf("%+v", err)
+			return m, err
 		}
+		m.Overrides = overrides
+		log.Printf("Loaded %d employee number overrides", len(overrides))
+	}
 
-		// update each matching driver in mike albert
-		for _, maDriver := range maDrivers {
-			// Compare current MA address with ADP address — only PATCH if different
-			newZip := d.ZIPCode
-			if len(newZip) > 5 {
-				newZip = newZip[:5]
-			}
-			currentZip := maDriver.Address.PostCode
-			if len(currentZip) > 5 {
-				currentZip = currentZip[:5]
-			}
-
-			if strings.EqualFold(strings.TrimSpace(maDriver.Address.Address1), strings.TrimSpace(d.Address1)) &&
-				strings.EqualFold(strings.TrimSpace(maDriver.Address.Address2), strings.TrimSpace(d.Address2)) &&
-				currentZip == newZip {
-				unchanged++
-				continue
-			}
-
-			log.Printf("  Updating DriverId %d (%s): '%s' -> '%s', '%s' -> '%s', '%s' -> '%s'",
-				*maDriver.DriverId, employeeNumber,
-				maDriver.Address.Address1, d.Address1,
-				maDriver.Address.Address2, d.Address2,
-				maDriver.Address.PostCode, d.ZIPCode)
-
-			_, err = mac.UpdateDriver(*maDriver.DriverId, d.Address1, d.Address2, d.ZIPCode)
-			if err != nil {
-				if strings.Contains(err.Error(), "multiple vehicles allocated") {
-					log.Printf("  WARN: DriverId %d has multiple vehicles - skipping address update", *maDriver.DriverId)
-					skipped++
-				} else {
-					log.Printf("  ERROR updating DriverId %d for EmployeeNumber %s: %+v", *maDriver.DriverId, employeeNumber, err)
-					errors++
-				}
-				continue
-			}
+	return m, nil
+}
 
-			log.Printf("  SUCCESS: Updated DriverId %d", *maDriver.DriverId)
-			updated++
-		}
+// newMikeAlbertClient creates a Mike Albert client from the config, allowing the endpoints and driver
+// fields not yet confirmed against the API only when configured to
+func newMikeAlbertClient() (*mikealbert.Client, error) {
+	mac, err := mikealbert.NewClient(config.MikeAlbert.ClientId, config.MikeAlbert.ClientSecret, config.MikeAlbert.Endpoint)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
 	}
-
-	log.Printf("=== SYNC COMPLETE ===")
-	log.Printf("  Total ADP drivers:   %d", len(drivers))
-	log.Printf("  Updated:             %d", updated)
-	log.Printf("  Unchanged:           %d", unchanged)
-	log.Printf("  Not found in MA:     %d", notFound)
-	log.Printf("  Skipped (multi-veh): %d", skipped)
-	log.Printf("  Errors:              %d", errors)
+	mac.Unconfirmed = config.MikeAlbert.UnconfirmedAPI
+	return mac, nil
 }
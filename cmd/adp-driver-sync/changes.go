@@ -0,0 +1,317 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+)
+
+// Address components compared between ADP and Mike Albert
+const (
+	fieldAddress1 = "address1"
+	fieldAddress2 = "address2"
+	fieldCity     = "city"
+	fieldState    = "state"
+	fieldPostCode = "postCode"
+	fieldCountry  = "country"
+)
+
+// fieldChange is an address component that differs between Mike Albert and ADP
+type fieldChange struct {
+	Field             string
+	Current           string // value in Mike Albert
+	CurrentNormalized string
+	ADP               string // value from ADP
+	ADPNormalized     string
+	Sent              string // value sent to Mike Albert
+	LastSynced        string // value last known to be in sync, blank if never synced
+	Conflict          bool   // Mike Albert was edited since the last sync
+	Result            string // result of sending the change's field group
+}
+
+// driverUpdate is the set of changes for a single Mike Albert driver
+type driverUpdate struct {
+	DriverId       int
+	EmployeeNumber string
+	AddressSource  string             // ADP address the changes came from
+	Current        mikealbert.Address // driver's address in Mike Albert before the update
+	Changes        []fieldChange
+	Result         string // result of the whole update when it was not sent, such as aborted
+}
+
+// sent returns the value sent for each changed field
+func (u driverUpdate) sent() map[string]string {
+	return sentFields(u.Changes)
+}
+
+// sentFields returns the value sent for each of the changes
+func sentFields(changes []fieldChange) map[string]string {
+	fields := make(map[string]string, len(changes))
+	for _, c := range changes {
+		fields[c.Field] = c.Sent
+	}
+	return fields
+}
+
+// Groups of driver fields sent to Mike Albert in separate updates, so a value Mike Albert rejects in
+// one group doesn't lose the changes in the others
+const (
+	groupAddress = "address"
+	groupName    = "name"
+	groupContact = "contact"
+)
+
+// fieldGroups are the field groups in the order they are sent, the address first
+var fieldGroups = []string{groupAddress, groupName, groupContact}
+
+// fieldGroup returns the group a driver field is sent in
+func fieldGroup(field string) string {
+	switch {
+	case isNameField(field):
+		return groupName
+	case isContactField(field):
+		return groupContact
+	}
+	return groupAddress
+}
+
+// group returns the changes of a driver update in a field group
+func (u driverUpdate) group(group string) []fieldChange {
+	var changes []fieldChange
+	for _, c := range u.Changes {
+		if fieldGroup(c.Field) == group {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// setResult records the result of sending a field group on each of its changes
+func (u *driverUpdate) setResult(group, result string) {
+	for i := range u.Changes {
+		if fieldGroup(u.Changes[i].Field) == group {
+			u.Changes[i].Result = result
+		}
+	}
+}
+
+// component is an address component along with how to normalize it for comparison
+type component struct {
+	field     string
+	current   string
+	incoming  string
+	normalize func(string) string
+}
+
+// components returns the address components of the Mike Albert and ADP addresses
+func components(current mikealbert.Address, d adp.DriverHomeAddress) []component {
+	postCode := func(s string) string {
+		pc, err := address.NormalizePostalCode(d.Country, s, config.MikeAlbert.PreserveZIP4)
+		if err != nil {
+			return s
+		}
+		return pc
+	}
+
+	return []component{
+		{fieldAddress1, current.Address1, d.Address1, address.NormalizeLine},
+		{fieldAddress2, current.Address2, d.Address2, address.NormalizeLine},
+		{fieldCity, current.City, d.City, address.NormalizeCity},
+		{fieldState, current.State, d.State, address.NormalizeState},
+		{fieldPostCode, current.PostCode, d.ZIPCode, postCode},
+		{fieldCountry, current.Country, d.Country, address.Country},
+	}
+}
+
+// same checks if the current value of a component already matches the incoming value
+func (c component) same(country string) bool {
+	if c.field == fieldPostCode {
+		return address.SamePostalCode(country, c.current, c.incoming, config.MikeAlbert.PreserveZIP4)
+	}
+	return c.normalize(c.current) == c.normalize(c.incoming)
+}
+
+// outgoing returns the value of a component to send to Mike Albert, postal code, country and
+// contact details are always sent normalized and names never are
+func (c component) outgoing() string {
+	if isNameField(c.field) {
+		return c.incoming
+	}
+	if config.Address.NormalizeWrites || c.field == fieldPostCode || c.field == fieldCountry || isContactField(c.field) {
+		return c.normalize(c.incoming)
+	}
+	return c.incoming
+}
+
+// allowed checks the configured field policy to see if a differing component may be updated
+func (c component) allowed() bool {
+	policy := config.Field(c.field)
+	switch {
+	case policy.Update == "never":
+		return false
+	case policy.Update == "fillempty" && len(c.normalize(c.current)) > 0:
+		return false
+	case policy.Clear == "forbidden" && len(strings.TrimSpace(c.incoming)) == 0:
+		return false
+	}
+	return true
+}
+
+// diffAddress returns the address components that differ between Mike Albert and ADP after
+// normalization and that the field policies allow to be updated. Components where Mike Albert no
+// longer holds the value last synced are flagged as conflicts.
+func diffAddress(current mikealbert.Address, d adp.DriverHomeAddress, snapshot map[string]string) []fieldChange {
+	return diffComponents(components(current, d), d.Country, snapshot)
+}
+
+// diffComponents returns the components that differ after normalization and that the field
+// policies allow to be updated, flagging conflicts against the snapshot
+func diffComponents(cs []component, country string, snapshot map[string]string) []fieldChange {
+	var changes []fieldChange
+	for _, c := range cs {
+		if c.same(country) || !c.allowed() {
+			continue
+		}
+		lastSynced, synced := snapshot[c.field]
+		changes = append(changes, fieldChange{
+			Field:             c.field,
+			Current:           c.current,
+			CurrentNormalized: c.normalize(c.current),
+			ADP:               c.incoming,
+			ADPNormalized:     c.normalize(c.incoming),
+			Sent:              c.outgoing(),
+			LastSynced:        lastSynced,
+			Conflict:          synced && c.normalize(lastSynced) != c.normalize(c.current),
+		})
+	}
+	return changes
+}
+
+// matchingFields returns the Mike Albert value of each address component that already matches ADP
+func matchingFields(current mikealbert.Address, d adp.DriverHomeAddress) map[string]string {
+	return matchingComponents(components(current, d), d.Country)
+}
+
+// matchingComponents returns the Mike Albert value of each component that already matches ADP
+func matchingComponents(cs []component, country string) map[string]string {
+	fields := make(map[string]string)
+	for _, c := range cs {
+		if c.same(country) {
+			fields[c.field] = c.current
+		}
+	}
+	return fields
+}
+
+// buildUpdate returns the partial driver update sending only the changed components, names and contact details.
+// Send each field group in its own update.
+func buildUpdate(changes []fieldChange) mikealbert.DriverUpdate {
+	var update mikealbert.DriverUpdate
+	for _, c := range changes {
+		v := c.Sent
+		switch c.Field {
+		case fieldFirstName:
+			update.FirstName = &v
+			continue
+		case fieldLastName:
+			update.LastName = &v
+			continue
+		case fieldEmail:
+			update.Email = &v
+			continue
+		case fieldMobilePhone:
+			update.MobilePhone = &v
+			continue
+		case fieldHomePhone:
+			update.HomePhone = &v
+			continue
+		}
+
+		if update.Address == nil {
+			update.Address = &mikealbert.AddressUpdate{}
+		}
+		switch c.Field {
+		case fieldAddress1:
+			update.Address.Address1 = &v
+		case fieldAddress2:
+			update.Address.Address2 = &v
+		case fieldCity:
+			update.Address.City = &v
+		case fieldState:
+			update.Address.State = &v
+		case fieldPostCode:
+			update.Address.PostCode = &v
+		case fieldCountry:
+			update.Address.Country = &v
+		}
+	}
+	return update
+}
+
+// outgoingAddress returns the complete address to send to Mike Albert for an ADP driver
+func outgoingAddress(d adp.DriverHomeAddress) mikealbert.Address {
+	var a mikealbert.Address
+	for _, c := range components(mikealbert.Address{}, d) {
+		v := c.outgoing()
+		switch c.field {
+		case fieldAddress1:
+			a.Address1 = v
+		case fieldAddress2:
+			a.Address2 = v
+		case fieldCity:
+			a.City = v
+		case fieldState:
+			a.State = v
+		case fieldPostCode:
+			a.PostCode = v
+		case fieldCountry:
+			a.Country = v
+		}
+	}
+	return a
+}
+
+// addressFields returns the fields of a Mike Albert address by component
+func addressFields(a mikealbert.Address) map[string]string {
+	return map[string]string{
+		fieldAddress1: a.Address1,
+		fieldAddress2: a.Address2,
+		fieldCity:     a.City,
+		fieldState:    a.State,
+		fieldPostCode: a.PostCode,
+		fieldCountry:  a.Country,
+	}
+}
+
+// writeChangesReport writes every changed address component, name and contact detail, raw and normalized, to the reports directory
+func writeChangesReport(updates []driverUpdate) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	var rows [][]string
+	for _, u := range updates {
+		for _, c := range u.Changes {
+			result := c.Result
+			if len(result) == 0 {
+				result = u.Result
+			}
+			rows = append(rows, []string{strconv.Itoa(u.DriverId), u.EmployeeNumber, c.Field, c.Current, c.CurrentNormalized, c.ADP, c.ADPNormalized, c.Sent, u.AddressSource, result})
+		}
+	}
+
+	path, err := report.Write(config.Reports.Directory, "changes", []string{"DriverId", "EmployeeNumber", "Field", "MikeAlbert", "MikeAlbertNormalized", "ADP", "ADPNormalized", "Sent", "AddressSource", "Result"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote changes report %s", path)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFieldGroups(t *testing.T) {
+	u := driverUpdate{Changes: []fieldChange{
+		{Field: fieldLastName, Sent: "Lee"},
+		{Field: fieldCity, Sent: "Columbus"},
+		{Field: fieldEmail, Sent: "ann@example.com"},
+		{Field: fieldPostCode, Sent: "43215"},
+	}}
+
+	tests := []struct {
+		group  string
+		fields []string
+	}{
+		{groupAddress, []string{fieldCity, fieldPostCode}},
+		{groupName, []string{fieldLastName}},
+		{groupContact, []string{fieldEmail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			changes := u.group(tt.group)
+			if len(changes) != len(tt.fields) {
+				t.Fatalf("group(%s) = %v, want %v", tt.group, changes, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if changes[i].Field != field {
+					t.Errorf("group(%s) has %s, want %s", tt.group, changes[i].Field, field)
+				}
+			}
+
+			// an update of the group's own sends none of the other groups' fields
+			update := buildUpdate(changes)
+			if tt.group != groupAddress && update.Address != nil {
+				t.Errorf("update for %s sends the address", tt.group)
+			}
+			if tt.group != groupName && (update.FirstName != nil || update.LastName != nil) {
+				t.Errorf("update for %s sends names", tt.group)
+			}
+			if tt.group != groupContact && (update.Email != nil || update.MobilePhone != nil || update.HomePhone != nil) {
+				t.Errorf("update for %s sends contact details", tt.group)
+			}
+		})
+	}
+
+	// a rejected name change leaves the address result as it was
+	u.setResult(groupAddress, "updated")
+	u.setResult(groupName, "error")
+	want := map[string]string{fieldLastName: "error", fieldCity: "updated", fieldEmail: "", fieldPostCode: "updated"}
+	for _, c := range u.Changes {
+		if c.Result != want[c.Field] {
+			t.Errorf("%s result = %q, want %q", c.Field, c.Result, want[c.Field])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Resolutions for fields edited in Mike Albert since the last sync
+const (
+	resolutionADP        = "adp"        // ADP wins, overwrite the edit
+	resolutionMikeAlbert = "mikealbert" // Mike Albert wins, keep the edit
+	resolutionManual     = "manual"     // keep the edit until someone decides
+)
+
+// conflict is a field edited in Mike Albert since the last sync that ADP would change
+type conflict struct {
+	DriverId       int
+	EmployeeNumber string
+	Change         fieldChange
+	Resolution     string
+}
+
+// resolveConflicts applies the configured conflict resolution, returning the changes to send
+func (run *syncRun) resolveConflicts(driverId int, employeeNumber string, changes []fieldChange) []fieldChange {
+	declined := run.state.Declined(driverId)
+	var keep, held []fieldChange
+	for _, c := range changes {
+		// an edit kept in mike albert stays until ADP changes
+		if v, ok := declined[c.Field]; ok && !c.Conflict && v == c.Sent {
+			continue
+		}
+		if !c.Conflict {
+			keep = append(keep, c)
+			continue
+		}
+
+		run.summary.Conflicts++
+		run.conflicts = append(run.conflicts, conflict{DriverId: driverId, EmployeeNumber: employeeNumber, Change: c, Resolution: config.Conflicts.Resolution})
+
+		switch config.Conflicts.Resolution {
+		case resolutionADP:
+			log.Printf("  WARN: DriverId %d (%s) %s was edited in Mike Albert since the last sync ('%s' -> '%s'), overwriting with ADP '%s'",
+				driverId, employeeNumber, c.Field, c.LastSynced, c.Current, c.Sent)
+			keep = append(keep, c)
+		case resolutionMikeAlbert:
+			log.Printf("  WARN: DriverId %d (%s) %s was edited in Mike Albert since the last sync ('%s' -> '%s'), keeping it over ADP '%s'",
+				driverId, employeeNumber, c.Field, c.LastSynced, c.Current, c.Sent)
+			run.state.RecordKept(driverId, c.Field, c.Current, c.Sent)
+		default:
+			log.Printf("  WARN: DriverId %d (%s) %s was edited in Mike Albert since the last sync ('%s' -> '%s'), held for manual review against ADP '%s'",
+				driverId, employeeNumber, c.Field, c.LastSynced, c.Current, c.Sent)
+			held = append(held, c)
+		}
+	}
+
+	if len(held) > 0 {
+		r := state.Review{
+			DriverId:       driverId,
+			EmployeeNumber: employeeNumber,
+			Reason:         "edited in Mike Albert since the last sync",
+			Current:        make(map[string]string),
+			Proposed:       make(map[string]string),
+		}
+		for _, c := range held {
+			r.Current[c.Field], r.Proposed[c.Field] = c.Current, c.Sent
+		}
+		run.queueReview(reviewConflict, strconv.Itoa(driverId), r)
+	}
+	return keep
+}
+
+// writeConflictsReport writes fields edited in Mike Albert since the last sync to the reports directory
+func writeConflictsReport(conflicts []conflict) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		rows = append(rows, []string{strconv.Itoa(c.DriverId), c.EmployeeNumber, c.Change.Field, c.Change.LastSynced, c.Change.Current, c.Change.ADP, c.Change.Sent, c.Resolution})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "conflicts", []string{"DriverId", "EmployeeNumber", "Field", "LastSynced", "MikeAlbert", "ADP", "Sent", "Resolution"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote conflicts report %s", path)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	edited := fieldChange{Field: fieldCity, Current: "Dublin", ADP: "Columbus", Sent: "Columbus", LastSynced: "Cbus", Conflict: true}
+	plain := fieldChange{Field: fieldPostCode, Current: "43215", ADP: "43017", Sent: "43017"}
+
+	tests := []struct {
+		resolution string
+		sent       []string
+		reviews    int
+	}{
+		{resolutionADP, []string{fieldPostCode, fieldCity}, 0},
+		{resolutionMikeAlbert, []string{fieldPostCode}, 0},
+		{resolutionManual, []string{fieldPostCode}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resolution, func(t *testing.T) {
+			config.Conflicts.Resolution = tt.resolution
+			run := &syncRun{state: &state.State{}}
+
+			keep := run.resolveConflicts(42, "1001", []fieldChange{plain, edited})
+			if len(keep) != len(tt.sent) {
+				t.Fatalf("resolveConflicts() kept %v, want %v", keep, tt.sent)
+			}
+			for i, field := range tt.sent {
+				if keep[i].Field != field {
+					t.Errorf("resolveConflicts() kept %s, want %s", keep[i].Field, field)
+				}
+			}
+			if run.summary.Conflicts != 1 || len(run.conflicts) != 1 {
+				t.Errorf("resolveConflicts() counted %d conflicts, reported %d, want 1", run.summary.Conflicts, len(run.conflicts))
+			}
+			if len(run.state.Reviews) != tt.reviews {
+				t.Errorf("resolveConflicts() queued %d reviews, want %d", len(run.state.Reviews), tt.reviews)
+			}
+		})
+	}
+}
+
+func TestKeptEditNotFlaggedAgain(t *testing.T) {
+	config.Conflicts.Resolution = resolutionMikeAlbert
+	run := &syncRun{state: &state.State{}}
+	run.state.RecordSynced(42, map[string]string{fieldCity: "Cbus"})
+
+	// the first run keeps the edit, later runs see it as synced and leave it while ADP is unchanged
+	edited := fieldChange{Field: fieldCity, Current: "Dublin", ADP: "Columbus", Sent: "Columbus", LastSynced: "Cbus", Conflict: true}
+	run.resolveConflicts(42, "1001", []fieldChange{edited})
+	if got := run.state.Snapshot(42)[fieldCity]; got != "Dublin" {
+		t.Fatalf("snapshot city = %q after keeping the edit, want Dublin", got)
+	}
+
+	again := fieldChange{Field: fieldCity, Current: "Dublin", ADP: "Columbus", Sent: "Columbus", LastSynced: "Dublin"}
+	if keep := run.resolveConflicts(42, "1001", []fieldChange{again}); len(keep) != 0 {
+		t.Errorf("resolveConflicts() sent %v for a kept edit, want nothing", keep)
+	}
+	if run.summary.Conflicts != 1 {
+		t.Errorf("resolveConflicts() counted %d conflicts, want 1", run.summary.Conflicts)
+	}
+
+	moved := fieldChange{Field: fieldCity, Current: "Dublin", ADP: "Westerville", Sent: "Westerville", LastSynced: "Dublin"}
+	if keep := run.resolveConflicts(42, "1001", []fieldChange{moved}); len(keep) != 1 {
+		t.Errorf("resolveConflicts() sent %v after ADP changed, want the new city", keep)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/contact"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+)
+
+// Driver contact fields compared between ADP and Mike Albert
+const (
+	fieldEmail       = "email"
+	fieldMobilePhone = "mobilePhone"
+	fieldHomePhone   = "homePhone"
+)
+
+// isContactField checks if a field is a driver contact detail
+func isContactField(field string) bool {
+	return field == fieldEmail || field == fieldMobilePhone || field == fieldHomePhone
+}
+
+// contactComponents returns the contact fields enabled for sync, along with the validation of the ADP value
+func contactComponents(maDriver mikealbert.Driver, d adp.DriverHomeAddress) ([]component, []func(string) (string, error)) {
+	phone := func(s string) (string, error) { return contact.NormalizePhone(d.Country, s) }
+
+	var cs []component
+	var validate []func(string) (string, error)
+	add := func(enabled bool, field, current, incoming string, normalize func(string) (string, error)) {
+		if !enabled {
+			return
+		}
+		cs = append(cs, component{field, current, incoming, func(s string) string {
+			if len(strings.TrimSpace(s)) == 0 {
+				return ""
+			}
+			if n, err := normalize(s); err == nil {
+				return n
+			}
+			return strings.TrimSpace(s)
+		}})
+		validate = append(validate, normalize)
+	}
+	add(config.Contact.Email, fieldEmail, maDriver.Email, d.Email, contact.NormalizeEmail)
+	add(config.Contact.MobilePhone, fieldMobilePhone, maDriver.MobilePhone, d.MobilePhone, phone)
+	add(config.Contact.HomePhone, fieldHomePhone, maDriver.HomePhone, d.HomePhone, phone)
+
+	return cs, validate
+}
+
+// diffContact returns the enabled contact fields that differ between Mike Albert and ADP. Blank or
+// invalid ADP values are never sent, invalid ones are logged and counted.
+func (run *syncRun) diffContact(maDriver mikealbert.Driver, d adp.DriverHomeAddress, employeeNumber string, snapshot map[string]string) []fieldChange {
+	all, validate := contactComponents(maDriver, d)
+
+	var cs []component
+	for i, c := range all {
+		if len(strings.TrimSpace(c.incoming)) == 0 {
+			continue
+		}
+		if _, err := validate[i](c.incoming); err != nil {
+			log.Printf("  WARN: DriverId %d (%s) %s not synced: %+v", *maDriver.DriverId, employeeNumber, c.field, err)
+			run.summary.InvalidContacts++
+			continue
+		}
+		cs = append(cs, c)
+	}
+	return diffComponents(cs, d.Country, snapshot)
+}
+
+// matchingContactFields returns the Mike Albert value of each enabled contact field that already matches ADP
+func matchingContactFields(maDriver mikealbert.Driver, d adp.DriverHomeAddress) map[string]string {
+	cs, _ := contactComponents(maDriver, d)
+	return matchingComponents(cs, d.Country)
+}
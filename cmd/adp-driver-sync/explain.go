@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/keymap"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// traceDecisions indexes the eligibility decisions by worker, so planning the sync can record the
+// rules applied after eligibility in the same trace
+func traceDecisions(decisions []adp.Decision) map[string]*adp.Decision {
+	trace := make(map[string]*adp.Decision, len(decisions))
+	for i := range decisions {
+		if key := workerKey(decisions[i].AssociateOID, decisions[i].WorkerID); len(key) > 0 {
+			trace[key] = &decisions[i]
+		}
+	}
+	return trace
+}
+
+// note records a value found while planning the sync of an ADP driver in their eligibility trace
+func (run *syncRun) note(d adp.DriverHomeAddress, name, value string) {
+	if decision, ok := run.decisions[linkKey(d)]; ok {
+		decision.Note(name, value)
+	}
+}
+
+// exclude records in an ADP driver's eligibility trace that planning the sync excluded them by rule
+func (run *syncRun) exclude(d adp.DriverHomeAddress, rule, name, value string) {
+	if decision, ok := run.decisions[linkKey(d)]; ok {
+		decision.Exclude(rule, name, value)
+	}
+}
+
+// runExplain prints the eligibility decision for the ADP worker(s) matching employeeNumber, along
+// with what planning the sync found for them in Mike Albert, without updating anything
+func runExplain(ac *adp.Client, employeeNumber string) error {
+	drivers, decisions, err := ac.GetDriverHomeAddresses(eligibilityRules())
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	keys, err := employeeNumberMapper()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// plan the sync of the matching eligible workers, the plan stage excludes workers too
+	workers := make(map[string]bool)
+	for _, d := range decisions {
+		if d.Matches(employeeNumber) && d.Eligible {
+			workers[workerKey(d.AssociateOID, d.WorkerID)] = true
+		}
+	}
+	var matched []adp.DriverHomeAddress
+	for _, d := range drivers {
+		if workers[linkKey(d)] {
+			matched = append(matched, d)
+		}
+	}
+	if len(matched) > 0 {
+		run, err := explainRun(keys)
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+		run.decisions = traceDecisions(decisions)
+		run.plan(matched)
+	}
+
+	found := 0
+	for _, d := range decisions {
+		if !d.Matches(employeeNumber) {
+			continue
+		}
+		found++
+
+		outcome := "EXCLUDED"
+		if d.Eligible {
+			outcome = "ELIGIBLE"
+		}
+		fmt.Printf("Employee %s (%s), worker ID %s, associate OID %s\n", d.EmployeeNumber, d.Name, d.WorkerID, d.AssociateOID)
+		fmt.Printf("  %s: %s\n", outcome, d.Rule)
+		if len(d.EmployeeNumber) > 0 {
+			fmt.Printf("  Mike Albert employee number: %s\n", keys.Map(d.EmployeeNumber, d.CompanyCode))
+		}
+		for _, v := range d.Values {
+			fmt.Printf("    %s\n", v)
+		}
+	}
+
+	if found == 0 {
+		fmt.Printf("No ADP worker found for %s\n", employeeNumber)
+	}
+
+	return nil
+}
+
+// explainRun sets up a sync run to plan, but never apply, the sync of the workers being explained
+func explainRun(keys keymap.Mapper) (*syncRun, error) {
+	mac, err := newMikeAlbertClient()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	schema, err := driverSchema()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	cities, err := cityReference()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	// the state is read for links and snapshots but never saved
+	st, err := state.Load(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return &syncRun{mac: mac, keys: keys, cities: cities, schema: schema, state: st}, nil
+}
+
+// writeEligibilityReport writes the eligibility decision for every ADP worker to the reports directory
+func writeEligibilityReport(decisions []adp.Decision) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(decisions))
+	for _, d := range decisions {
+		rows = append(rows, []string{d.EmployeeNumber, d.WorkerID, d.AssociateOID, d.Name, strconv.FormatBool(d.Eligible), d.Rule, d.Detail()})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "eligibility", []string{"EmployeeNumber", "WorkerID", "AssociateOID", "Name", "Eligible", "Rule", "Values"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote eligibility report %s", path)
+
+	return nil
+}
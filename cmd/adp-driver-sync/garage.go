@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+)
+
+// nonGarageable is an ADP driver whose address is a PO box or other address a vehicle can't be garaged at
+type nonGarageable struct {
+	Driver adp.DriverHomeAddress
+	Kind   string
+	Result string
+}
+
+// garageable checks if a vehicle could be garaged at an address, which rules out PO boxes and other mail-only addresses
+func garageable(line1, line2, city, state string) bool {
+	return len(address.NonGarageable(line1, line2, city, state)) == 0
+}
+
+// checkGarageable applies the non-garageable address policy to an ADP driver, returning false when
+// the driver must not be synced. With the fallback policy the address has already been taken from
+// the next source when there was one, so a mail-only address here means no source had better.
+func (run *syncRun) checkGarageable(d adp.DriverHomeAddress) bool {
+	kind := address.NonGarageable(d.Address1, d.Address2, d.City, d.State)
+	if len(kind) == 0 {
+		return true
+	}
+
+	n := nonGarageable{Driver: d, Kind: kind}
+	synced := false
+	switch config.Address.NonGarageablePolicy {
+	case policyFlag:
+		log.Printf("  WARN: EmployeeNumber %s has a %s address (%s), syncing anyway", d.EmployeeNumber, kind, d.AddressSource)
+		n.Result = "flagged"
+		synced = true
+	case policyFallback:
+		log.Printf("  WARN: EmployeeNumber %s has a %s address and no other address source, skipping", d.EmployeeNumber, kind)
+		n.Result = "skipped, no other address source"
+	default:
+		log.Printf("  WARN: EmployeeNumber %s has a %s address (%s), skipping", d.EmployeeNumber, kind, d.AddressSource)
+		n.Result = "skipped"
+	}
+
+	run.nonGarageable = append(run.nonGarageable, n)
+	run.summary.NonGarageable++
+	if synced {
+		run.note(d, "nonGarageable", kind)
+	} else {
+		run.exclude(d, adp.RuleNonGarageable, "nonGarageable", kind)
+	}
+	return synced
+}
+
+// writeNonGarageableReport writes the ADP drivers with mail-only addresses to the reports directory
+func writeNonGarageableReport(addresses []nonGarageable) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(addresses))
+	for _, n := range addresses {
+		d := n.Driver
+		rows = append(rows, []string{d.EmployeeNumber, fmt.Sprintf("%s %s", d.FirstName, d.LastName), n.Kind, d.AddressSource,
+			d.Address1, d.Address2, d.City, d.State, d.ZIPCode, d.Country, n.Result})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "non-garageable", []string{"EmployeeNumber", "Name", "Kind", "AddressSource", "Address1", "Address2", "City", "State", "PostCode", "Country", "Result"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote non-garageable report %s", path)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// percent returns part as a percentage of whole
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(whole)
+}
+
+// plannedWrites counts the drivers a run would write to in Mike Albert: address, name and contact
+// updates, offboarded terminations, reactivated rehires and drivers created
+func (run *syncRun) plannedWrites() int {
+	writes := len(run.updates)
+	if config.Terminations.Action != terminationReport {
+		for _, t := range run.terminations {
+			if len(t.DriverIds) == 1 {
+				writes++
+			}
+		}
+	}
+	if config.Rehires.Action == rehireReactivate {
+		for _, r := range run.rehires {
+			if r.DriverId > 0 {
+				writes++
+			}
+		}
+	}
+	if config.Onboarding.Enabled && config.Onboarding.AutoCreate {
+		writes += len(run.newHires)
+	}
+	return writes
+}
+
+// checkGuardrails checks the planned changes against the configured limits and the previous run,
+// returning an error describing every limit exceeded
+func checkGuardrails(summary syncSummary, changes int, lastRun *state.Run) error {
+	g := config.Guardrails
+	var problems []string
+
+	if g.MaxChanges > 0 && changes > g.MaxChanges {
+		problems = append(problems, fmt.Sprintf("%d drivers would be changed, offboarded or created, more than the limit of %d", changes, g.MaxChanges))
+	}
+	// with no matched drivers every write is new, such as from onboarding, terminations or rehires
+	if g.MaxChangePercent > 0 && changes > 0 && summary.Matched == 0 {
+		problems = append(problems, fmt.Sprintf("%d drivers would change with no matched drivers, more than the limit of %.1f%%", changes, g.MaxChangePercent))
+	} else if g.MaxChangePercent > 0 && percent(changes, summary.Matched) > g.MaxChangePercent {
+		problems = append(problems, fmt.Sprintf("%d of %d matched drivers (%.1f%%) would change, more than the limit of %.1f%%",
+			changes, summary.Matched, percent(changes, summary.Matched), g.MaxChangePercent))
+	}
+
+	// compare against the previous run, if there was one
+	if lastRun != nil {
+		if g.MinWorkerPercent > 0 && lastRun.Workers > 0 && percent(summary.Workers, lastRun.Workers) < g.MinWorkerPercent {
+			problems = append(problems, fmt.Sprintf("ADP returned %d workers, %.1f%% of the %d returned on %s, less than the limit of %.1f%%",
+				summary.Workers, percent(summary.Workers, lastRun.Workers), lastRun.Workers, lastRun.Time.Format("2006-01-02"), g.MinWorkerPercent))
+		}
+		if g.MinEligiblePercent > 0 && lastRun.Eligible > 0 && percent(summary.Drivers, lastRun.Eligible) < g.MinEligiblePercent {
+			problems = append(problems, fmt.Sprintf("%d workers are eligible, %.1f%% of the %d eligible on %s, less than the limit of %.1f%%",
+				summary.Drivers, percent(summary.Drivers, lastRun.Eligible), lastRun.Eligible, lastRun.Time.Format("2006-01-02"), g.MinEligiblePercent))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("guardrails exceeded: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+func TestCheckGuardrails(t *testing.T) {
+	config.Guardrails.MaxChanges = 10
+	config.Guardrails.MaxChangePercent = 20
+	config.Guardrails.MinWorkerPercent = 80
+	config.Guardrails.MinEligiblePercent = 80
+	lastRun := &state.Run{Time: time.Now(), Workers: 100, Eligible: 50, Matched: 50}
+
+	tests := []struct {
+		name     string
+		summary  syncSummary
+		changes  int
+		lastRun  *state.Run
+		exceeded bool
+	}{
+		{"at the change limit", syncSummary{Workers: 100, Drivers: 100, Matched: 100}, 10, nil, false},
+		{"over the change limit", syncSummary{Workers: 100, Drivers: 100, Matched: 100}, 11, nil, true},
+		{"at the change percent", syncSummary{Workers: 100, Drivers: 50, Matched: 50}, 10, nil, false},
+		{"over the change percent", syncSummary{Workers: 100, Drivers: 50, Matched: 45}, 10, nil, true},
+		{"changes with no matched drivers", syncSummary{Workers: 100, Drivers: 5}, 1, nil, true},
+		{"nothing matched or changed", syncSummary{Workers: 100}, 0, nil, false},
+		{"at the worker percent", syncSummary{Workers: 80, Drivers: 50, Matched: 50}, 0, lastRun, false},
+		{"below the worker percent", syncSummary{Workers: 79, Drivers: 50, Matched: 50}, 0, lastRun, true},
+		{"at the eligible percent", syncSummary{Workers: 100, Drivers: 40, Matched: 40}, 0, lastRun, false},
+		{"below the eligible percent", syncSummary{Workers: 100, Drivers: 39, Matched: 39}, 0, lastRun, true},
+		{"first run has nothing to compare", syncSummary{Workers: 1, Drivers: 1, Matched: 1}, 0, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGuardrails(tt.summary, tt.changes, tt.lastRun)
+			if (err != nil) != tt.exceeded {
+				t.Errorf("checkGuardrails() = %v, exceeded %v", err, tt.exceeded)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// nameMismatch is a Mike Albert driver held back because their name does not match the ADP worker
+type nameMismatch struct {
+	DriverId       int
+	EmployeeNumber string
+	ADPName        string
+	MikeAlbertName string
+	Score          float64
+}
+
+// Policies for an employee number that finds several Mike Albert drivers
+const (
+	multipleAll    = "all"    // update every driver found
+	multipleActive = "active" // update only active drivers
+	multipleNone   = "none"   // update none of them
+	multipleName   = "name"   // update the single driver whose name best matches ADP
+)
+
+// multipleMatch is an employee number that found several Mike Albert drivers
+type multipleMatch struct {
+	EmployeeNumber string
+	ADPName        string
+	Candidates     []mikealbert.Driver
+	Selected       []mikealbert.Driver
+}
+
+// driverIds returns the driver IDs of drivers as a single string
+func driverIds(drivers []mikealbert.Driver) string {
+	ids := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		ids = append(ids, strconv.Itoa(*d.DriverId))
+	}
+	return strings.Join(ids, " ")
+}
+
+// bestNameMatch returns the single driver whose name best matches the ADP worker above the
+// threshold, nil when none does or the best is tied
+func bestNameMatch(candidates []mikealbert.Driver, d adp.DriverHomeAddress) []mikealbert.Driver {
+	best, bestScore, tied := -1, config.Identity.NameThreshold, false
+	for i, c := range candidates {
+		score := nameSimilarity(d, c.FirstName, c.LastName)
+		switch {
+		case score > bestScore || (best < 0 && score == bestScore):
+			best, bestScore, tied = i, score, false
+		case best >= 0 && score == bestScore:
+			tied = true
+		}
+	}
+	if best < 0 || tied {
+		return nil
+	}
+	return candidates[best : best+1]
+}
+
+// selectMatches applies the multiple match policy to the Mike Albert drivers an employee number
+// found, returning the drivers to update
+func selectMatches(candidates []mikealbert.Driver, d adp.DriverHomeAddress) []mikealbert.Driver {
+	if len(candidates) < 2 {
+		return candidates
+	}
+
+	var selected []mikealbert.Driver
+	switch config.Identity.MultipleMatches {
+	case multipleAll:
+		selected = candidates
+	case multipleActive:
+		for _, c := range candidates {
+			if c.IsActive() {
+				selected = append(selected, c)
+			}
+		}
+	case multipleName:
+		selected = bestNameMatch(candidates, d)
+	}
+	return selected
+}
+
+// nameScore scores the Mike Albert driver's name against the ADP worker, reporting false when the
+// driver has no name in Mike Albert to verify against
+func nameScore(maDriver mikealbert.Driver, d adp.DriverHomeAddress) (float64, bool) {
+	if len(strings.TrimSpace(maDriver.FirstName+maDriver.LastName)) == 0 {
+		return 0, false
+	}
+	return nameSimilarity(d, maDriver.FirstName, maDriver.LastName), true
+}
+
+// disambiguate applies the multiple match policy when an employee number finds several Mike Albert
+// drivers, returning the drivers to update
+func (run *syncRun) disambiguate(candidates []mikealbert.Driver, d adp.DriverHomeAddress, employeeNumber string) []mikealbert.Driver {
+	if len(candidates) < 2 {
+		return candidates
+	}
+
+	selected := selectMatches(candidates, d)
+
+	log.Printf("  WARN: EmployeeNumber %s found %d drivers in Mike Albert (%s), policy %s selected %d (%s)",
+		employeeNumber, len(candidates), driverIds(candidates), config.Identity.MultipleMatches, len(selected), driverIds(selected))
+	run.multipleMatches = append(run.multipleMatches, multipleMatch{
+		EmployeeNumber: employeeNumber,
+		ADPName:        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+		Candidates:     candidates,
+		Selected:       selected,
+	})
+	run.summary.MultipleMatches++
+
+	if len(selected) == 0 {
+		run.queueWorkerReview(reviewMultipleMatches, d, fmt.Sprintf("employee number found drivers %s, none selected by policy %s",
+			driverIds(candidates), config.Identity.MultipleMatches))
+	}
+
+	return selected
+}
+
+// writeMultipleMatchReport writes every employee number that found several Mike Albert drivers to the reports directory
+func writeMultipleMatchReport(matches []multipleMatch) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(matches))
+	for _, m := range matches {
+		rows = append(rows, []string{m.EmployeeNumber, m.ADPName, driverIds(m.Candidates), config.Identity.MultipleMatches, driverIds(m.Selected)})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "multiple-matches", []string{"EmployeeNumber", "ADPName", "CandidateDriverIds", "Policy", "SelectedDriverIds"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote multiple matches report %s", path)
+
+	return nil
+}
+
+// Outcomes of checking a Mike Albert driver's name against an ADP worker
+const (
+	identityMatch      = iota // names match, or names are not verified
+	identityUnnamed           // the driver has no name in Mike Albert to verify against
+	identityNameChange        // names differ but are accepted as a name change
+	identityMismatch          // names differ, the driver must not be updated
+)
+
+// checkIdentity checks the Mike Albert driver's name plausibly matches the ADP worker without recording
+// anything, returning the outcome and the name similarity. known is whether the worker has the same
+// employee number as in the previous sync.
+func checkIdentity(maDriver mikealbert.Driver, d adp.DriverHomeAddress, snapshot map[string]string, known bool) (int, float64) {
+	if !config.Identity.VerifyNames {
+		return identityMatch, 0
+	}
+
+	score, named := nameScore(maDriver, d)
+	switch {
+	case !named:
+		return identityUnnamed, 0
+	case score >= config.Identity.NameThreshold:
+		return identityMatch, score
+	case nameChanged(maDriver, d, snapshot, known):
+		return identityNameChange, score
+	}
+	return identityMismatch, score
+}
+
+// verifyIdentity checks the Mike Albert driver's name plausibly matches the ADP worker, returning
+// false when the driver must not be updated, and queues a mismatch for review
+func (run *syncRun) verifyIdentity(maDriver mikealbert.Driver, d adp.DriverHomeAddress, employeeNumber string) bool {
+	outcome, score := checkIdentity(maDriver, d, run.state.Snapshot(*maDriver.DriverId), run.knownWorkers[linkKey(d)])
+	switch outcome {
+	case identityMatch:
+		return true
+	case identityUnnamed:
+		log.Printf("  WARN: DriverId %d (%s) has no name in Mike Albert, cannot verify identity", *maDriver.DriverId, employeeNumber)
+		return true
+	case identityNameChange:
+		log.Printf("  DriverId %d (%s) is '%s %s' in Mike Albert but '%s %s' in ADP (similarity %.2f), accepted as a name change",
+			*maDriver.DriverId, employeeNumber, maDriver.FirstName, maDriver.LastName, d.FirstName, d.LastName, score)
+		return true
+	}
+
+	log.Printf("  WARN: DriverId %d (%s) is '%s %s' in Mike Albert but '%s %s' in ADP (similarity %.2f), holding back update",
+		*maDriver.DriverId, employeeNumber, maDriver.FirstName, maDriver.LastName, d.FirstName, d.LastName, score)
+	run.nameMismatches = append(run.nameMismatches, nameMismatch{
+		DriverId:       *maDriver.DriverId,
+		EmployeeNumber: employeeNumber,
+		ADPName:        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+		MikeAlbertName: fmt.Sprintf("%s %s", maDriver.FirstName, maDriver.LastName),
+		Score:          score,
+	})
+	run.summary.NameMismatches++
+	run.note(d, "nameMismatch", fmt.Sprintf("DriverId %d '%s %s' similarity %.2f", *maDriver.DriverId, maDriver.FirstName, maDriver.LastName, score))
+
+	current := addressFields(maDriver.Address)
+	current[fieldFirstName], current[fieldLastName] = maDriver.FirstName, maDriver.LastName
+	run.queueReview(reviewNameMismatch, strconv.Itoa(*maDriver.DriverId), state.Review{
+		Worker:         linkKey(d),
+		DriverId:       *maDriver.DriverId,
+		EmployeeNumber: employeeNumber,
+		Name:           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+		Reason:         fmt.Sprintf("name similarity %.2f below %.2f", score, config.Identity.NameThreshold),
+		Current:        current,
+		Proposed:       addressFields(outgoingAddress(d)),
+	})
+	return false
+}
+
+// writeNameMismatchReport writes the drivers held back by identity verification to the reports directory
+func writeNameMismatchReport(mismatches []nameMismatch) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		rows = append(rows, []string{strconv.Itoa(m.DriverId), m.EmployeeNumber, m.ADPName, m.MikeAlbertName, strconv.FormatFloat(m.Score, 'f', 2, 64)})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "name-mismatches", []string{"DriverId", "EmployeeNumber", "ADPName", "MikeAlbertName", "Similarity"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote name mismatches report %s", path)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+)
+
+// adjustment is a change made to an ADP value so Mike Albert accepts it
+type adjustment struct {
+	EmployeeNumber string
+	Field          string
+	ADP            string
+	Sent           string
+	Change         string
+}
+
+// driverSchema returns the Mike Albert driver field rules with the configured field limits applied
+func driverSchema() (mikealbert.Schema, error) {
+	schema := mikealbert.DefaultSchema()
+	for name, limit := range config.MikeAlbert.FieldLimits {
+		found := false
+		for field, rule := range schema {
+			if strings.EqualFold(field, name) {
+				rule.MaxLength = limit
+				schema[field] = rule
+				found = true
+			}
+		}
+		if !found {
+			err := fmt.Errorf("unknown Mike Albert field '%s' in field limits", name)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+// fitDriver makes an ADP driver's values acceptable to Mike Albert's field limits and character
+// sets, moving the end of an overlong address line one to address line two, and records every adjustment
+func (run *syncRun) fitDriver(d adp.DriverHomeAddress, employeeNumber string) adp.DriverHomeAddress {
+	fit := func(schema mikealbert.Schema, field string, value *string) {
+		fitted, changes := schema.Fit(field, *value, address.Fold)
+		for _, change := range changes {
+			log.Printf("  WARN: EmployeeNumber %s %s '%s' adjusted for Mike Albert: %s", employeeNumber, field, *value, change)
+			run.adjustments = append(run.adjustments, adjustment{employeeNumber, field, *value, fitted, change})
+			run.summary.Adjusted++
+		}
+		*value = fitted
+	}
+
+	// clean address line one without cutting it, then move what does not fit to line two
+	rule := run.schema[fieldAddress1]
+	unlimited := mikealbert.Schema{fieldAddress1: {Allowed: rule.Allowed, Transliterate: rule.Transliterate}}
+	fit(unlimited, fieldAddress1, &d.Address1)
+	if rule.MaxLength > 0 && len(d.Address1) > rule.MaxLength {
+		line1, overflow := mikealbert.SplitAtWord(d.Address1, rule.MaxLength)
+		change := fmt.Sprintf("longer than %d characters, '%s' moved to address2", rule.MaxLength, overflow)
+		log.Printf("  WARN: EmployeeNumber %s address1 '%s' adjusted for Mike Albert: %s", employeeNumber, d.Address1, change)
+		run.adjustments = append(run.adjustments, adjustment{employeeNumber, fieldAddress1, d.Address1, line1, change})
+		run.summary.Adjusted++
+		d.Address1 = line1
+		d.Address2 = strings.TrimSpace(overflow + " " + d.Address2)
+	}
+
+	fit(run.schema, fieldAddress2, &d.Address2)
+	fit(run.schema, fieldCity, &d.City)
+	fit(run.schema, fieldState, &d.State)
+	fit(run.schema, fieldPostCode, &d.ZIPCode)
+	fit(run.schema, fieldCountry, &d.Country)
+	fit(run.schema, fieldFirstName, &d.FirstName)
+	fit(run.schema, fieldLastName, &d.LastName)
+	fit(run.schema, fieldFirstName, &d.PreferredFirstName)
+	fit(run.schema, fieldLastName, &d.PreferredLastName)
+	fit(run.schema, fieldEmail, &d.Email)
+	fit(run.schema, fieldMobilePhone, &d.MobilePhone)
+	fit(run.schema, fieldHomePhone, &d.HomePhone)
+
+	return d
+}
+
+// writeAdjustmentsReport writes every ADP value adjusted for Mike Albert to the reports directory
+func writeAdjustmentsReport(adjustments []adjustment) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(adjustments))
+	for _, a := range adjustments {
+		rows = append(rows, []string{a.EmployeeNumber, a.Field, a.ADP, a.Sent, a.Change})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "adjustments", []string{"EmployeeNumber", "Field", "ADP", "Sent", "Adjustment"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote adjustments report %s", path)
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Sources of a link between an ADP worker and a Mike Albert driver
+const (
+	linkManual = "manual"
+	linkAuto   = "auto"
+)
+
+// workerKey returns the key an ADP worker is linked and tracked by, the associate OID or worker ID when there is none
+func workerKey(associateOID, workerID string) string {
+	if len(associateOID) > 0 {
+		return associateOID
+	}
+	return workerID
+}
+
+// linkKey returns the key an ADP driver is linked by
+func linkKey(d adp.DriverHomeAddress) string {
+	return workerKey(d.AssociateOID, d.WorkerID)
+}
+
+// findDrivers finds the Mike Albert drivers for an ADP driver, using the link table when the worker
+// is linked and finding by employee number otherwise. Reports whether the driver came from a link.
+func (run *syncRun) findDrivers(d adp.DriverHomeAddress, employeeNumber string) ([]mikealbert.Driver, bool, error) {
+	if link, ok := run.state.Link(linkKey(d)); ok {
+		maDriver, err := run.readDriver(link.DriverId, employeeNumber, link.EmployeeNumber)
+		if err != nil {
+			log.Printf("%+v", err)
+			return nil, true, err
+		}
+		if maDriver == nil {
+			return nil, true, nil
+		}
+		return []mikealbert.Driver{*maDriver}, true, nil
+	}
+
+	maDrivers, err := run.mac.FindDrivers(employeeNumber)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, false, err
+	}
+	return maDrivers, false, nil
+}
+
+// readDriver reads a Mike Albert driver by driver ID. Without the unconfirmed driver endpoint the
+// driver is found by each of the employee numbers in turn instead, such as the worker's current one
+// and the one recorded with their link, nil when none of the drivers found has the ID.
+func (run *syncRun) readDriver(driverId int, employeeNumbers ...string) (*mikealbert.Driver, error) {
+	if run.mac.Unconfirmed {
+		maDriver, err := run.mac.GetDriver(driverId)
+		if err != nil {
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		if maDriver.DriverId == nil {
+			maDriver.DriverId = &driverId
+		}
+		return maDriver, nil
+	}
+
+	return findDriverById(run.mac, driverId, employeeNumbers...)
+}
+
+// findDriverById finds a Mike Albert driver by employee number and driver ID, trying each employee
+// number in turn, nil when none of the drivers found has the ID
+func findDriverById(mac *mikealbert.Client, driverId int, employeeNumbers ...string) (*mikealbert.Driver, error) {
+	tried := map[string]bool{}
+	for _, employeeNumber := range employeeNumbers {
+		if len(employeeNumber) == 0 || tried[employeeNumber] {
+			continue
+		}
+		tried[employeeNumber] = true
+
+		maDrivers, err := mac.FindDrivers(employeeNumber)
+		if err != nil {
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		for i := range maDrivers {
+			if maDrivers[i].DriverId != nil && *maDrivers[i].DriverId == driverId {
+				return &maDrivers[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// holdMissingLink holds back a linked worker whose Mike Albert driver was not found, queueing them
+// for review instead of onboarding them again as a new driver
+func (run *syncRun) holdMissingLink(d adp.DriverHomeAddress, employeeNumber string) {
+	link, _ := run.state.Link(linkKey(d))
+	log.Printf("  WARN: linked DriverId %d not found by EmployeeNumber %s or %s, held for review", link.DriverId, employeeNumber, link.EmployeeNumber)
+	run.summary.NotFound++
+	run.exclude(d, adp.RuleLinkNotFound, "mikeAlbertDriverId", strconv.Itoa(link.DriverId))
+
+	run.queueReview(reviewLinkNotFound, linkKey(d), state.Review{
+		Worker:         linkKey(d),
+		DriverId:       link.DriverId,
+		EmployeeNumber: employeeNumber,
+		Name:           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+		Reason: fmt.Sprintf("linked DriverId %d not found by employee number %s or %s, relink or remove the link with the links command",
+			link.DriverId, employeeNumber, link.EmployeeNumber),
+		Proposed: addressFields(outgoingAddress(d)),
+	})
+}
+
+// runLinks manages the link table between ADP workers and Mike Albert drivers
+func runLinks(ac *adp.Client, args []string) error {
+	st, err := state.Load(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		listLinks(st)
+		return nil
+
+	case (len(args) == 3 || len(args) == 4) && args[0] == "add":
+		driverId, err := strconv.Atoi(args[2])
+		if err != nil {
+			err = fmt.Errorf("invalid Mike Albert driver ID '%s'", args[2])
+			log.Printf("%+v", err)
+			return err
+		}
+		employeeNumber := ""
+		if len(args) == 4 {
+			employeeNumber = args[3]
+		}
+		err = addLink(st, args[1], driverId, employeeNumber)
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+
+	case len(args) == 2 && args[0] == "remove":
+		if !st.RemoveLink(args[1]) {
+			err = fmt.Errorf("no link for ADP worker '%s'", args[1])
+			log.Printf("%+v", err)
+			return err
+		}
+		fmt.Printf("Removed link for %s\n", args[1])
+
+	case len(args) == 1 && args[0] == "auto":
+		err = autoLink(ac, st)
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+
+	default:
+		err = fmt.Errorf("usage: links list | links add <associateOID> <driverId> [employeeNumber] | links remove <associateOID> | links auto")
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = st.Save(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// listLinks prints the link table
+func listLinks(st *state.State) {
+	workers := make([]string, 0, len(st.Links))
+	for w := range st.Links {
+		workers = append(workers, w)
+	}
+	sort.Strings(workers)
+
+	fmt.Printf("%-30s %-10s %-15s %-30s %-7s %s\n", "ADP worker", "DriverId", "EmployeeNumber", "Name", "Source", "Created")
+	for _, w := range workers {
+		l := st.Links[w]
+		fmt.Printf("%-30s %-10d %-15s %-30s %-7s %s\n", w, l.DriverId, l.EmployeeNumber, l.Name, l.Source, l.Created.Format("2006-01-02"))
+	}
+	fmt.Printf("%d links\n", len(workers))
+}
+
+// addLink links an ADP worker to a Mike Albert driver after checking the driver exists, reading it by
+// driver ID with the unconfirmed driver endpoint and finding it by its employee number otherwise
+func addLink(st *state.State, worker string, driverId int, employeeNumber string) error {
+	mac, err := newMikeAlbertClient()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	var maDriver *mikealbert.Driver
+	switch {
+	case mac.Unconfirmed:
+		maDriver, err = mac.GetDriver(driverId)
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+	case len(employeeNumber) == 0:
+		err = fmt.Errorf("the Mike Albert employee number of DriverId %d is needed to find it, links add %s %d <employeeNumber>", driverId, worker, driverId)
+		log.Printf("%+v", err)
+		return err
+	default:
+		maDriver, err = findDriverById(mac, driverId, employeeNumber)
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+		if maDriver == nil {
+			err = fmt.Errorf("DriverId %d not found by employee number %s", driverId, employeeNumber)
+			log.Printf("%+v", err)
+			return err
+		}
+	}
+	if maDriver.EmployeeNumber != nil && len(*maDriver.EmployeeNumber) > 0 {
+		employeeNumber = *maDriver.EmployeeNumber
+	}
+
+	st.AddLink(worker, state.Link{
+		DriverId:       driverId,
+		EmployeeNumber: employeeNumber,
+		Name:           fmt.Sprintf("%s %s", maDriver.FirstName, maDriver.LastName),
+		Source:         linkManual,
+	})
+	fmt.Printf("Linked %s to DriverId %d (%s %s)\n", worker, driverId, maDriver.FirstName, maDriver.LastName)
+
+	return nil
+}
+
+// autoLink links every eligible ADP worker not yet linked whose employee number finds a single
+// Mike Albert driver, after the multiple match policy and identity verification
+func autoLink(ac *adp.Client, st *state.State) error {
+	mac, err := newMikeAlbertClient()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	keys, err := employeeNumberMapper()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	drivers, _, err := ac.GetDriverHomeAddresses(eligibilityRules())
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	added := 0
+	for _, d := range drivers {
+		key := linkKey(d)
+		if _, ok := st.Link(key); ok || len(key) == 0 {
+			continue
+		}
+
+		employeeNumber := keys.Map(d.EmployeeNumber, d.CompanyCode)
+		maDrivers, err := mac.FindDrivers(employeeNumber)
+		if err != nil {
+			log.Printf("ERROR finding driver %s in Mike Albert: %+v", employeeNumber, err)
+			continue
+		}
+
+		// check only, the next sync reports and queues for review the workers left unlinked
+		maDrivers = selectMatches(maDrivers, d)
+		if len(maDrivers) != 1 {
+			continue
+		}
+		worker, seen := st.Worker(key)
+		known := seen && len(d.EmployeeNumber) > 0 && worker.EmployeeNumber == d.EmployeeNumber
+		outcome, _ := checkIdentity(maDrivers[0], d, st.Snapshot(*maDrivers[0].DriverId), known)
+		if outcome == identityMismatch {
+			continue
+		}
+
+		st.AddLink(key, state.Link{
+			DriverId:       *maDrivers[0].DriverId,
+			EmployeeNumber: employeeNumber,
+			Name:           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			Source:         linkAuto,
+		})
+		added++
+	}
+
+	fmt.Printf("Added %d links, %d links in total\n", added, len(st.Links))
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/identity"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+)
+
+// Driver name fields compared between ADP and Mike Albert
+const (
+	fieldFirstName = "firstName"
+	fieldLastName  = "lastName"
+)
+
+// isNameField checks if a field is a driver name rather than an address component
+func isNameField(field string) bool {
+	return field == fieldFirstName || field == fieldLastName
+}
+
+// driverName returns the ADP worker's name to use in Mike Albert, the preferred name when configured
+// and the worker has one, otherwise the legal name
+func driverName(d adp.DriverHomeAddress) (string, string) {
+	first, last := d.FirstName, d.LastName
+	if config.Names.Source == "preferred" {
+		if len(strings.TrimSpace(d.PreferredFirstName)) > 0 {
+			first = d.PreferredFirstName
+		}
+		if len(strings.TrimSpace(d.PreferredLastName)) > 0 {
+			last = d.PreferredLastName
+		}
+	}
+	return strings.TrimSpace(first), strings.TrimSpace(last)
+}
+
+// nameComponents returns the name fields of the Mike Albert driver and ADP worker, compared
+// ignoring case, diacritics and punctuation
+func nameComponents(maDriver mikealbert.Driver, d adp.DriverHomeAddress) []component {
+	first, last := driverName(d)
+	return []component{
+		{fieldFirstName, maDriver.FirstName, first, identity.NormalizeName},
+		{fieldLastName, maDriver.LastName, last, identity.NormalizeName},
+	}
+}
+
+// diffName returns the name fields that differ between Mike Albert and ADP when name sync is on.
+// A blank ADP name never replaces a name in Mike Albert.
+func diffName(maDriver mikealbert.Driver, d adp.DriverHomeAddress, snapshot map[string]string) []fieldChange {
+	if !config.Names.Sync {
+		return nil
+	}
+
+	var cs []component
+	for _, c := range nameComponents(maDriver, d) {
+		if len(c.incoming) > 0 {
+			cs = append(cs, c)
+		}
+	}
+	return diffComponents(cs, d.Country, snapshot)
+}
+
+// matchingNameFields returns the Mike Albert value of each name field that already matches ADP when name sync is on
+func matchingNameFields(maDriver mikealbert.Driver, d adp.DriverHomeAddress) map[string]string {
+	if !config.Names.Sync {
+		return nil
+	}
+	return matchingComponents(nameComponents(maDriver, d), d.Country)
+}
+
+// nameChanged checks if a Mike Albert driver whose name no longer resembles the ADP worker's is
+// the same person after a name change, such as a new last name after marriage. Either first or last
+// name must still match exactly, and either Mike Albert still holds the name last synced for the
+// driver, or the worker kept the employee number they had in the previous sync.
+func nameChanged(maDriver mikealbert.Driver, d adp.DriverHomeAddress, snapshot map[string]string, known bool) bool {
+	sameFirst := identity.SameName(maDriver.FirstName, d.FirstName) ||
+		(len(d.PreferredFirstName) > 0 && identity.SameName(maDriver.FirstName, d.PreferredFirstName))
+	sameLast := identity.SameName(maDriver.LastName, d.LastName) ||
+		(len(d.PreferredLastName) > 0 && identity.SameName(maDriver.LastName, d.PreferredLastName))
+	if len(identity.NormalizeName(maDriver.FirstName)) == 0 || (!sameFirst && !sameLast) {
+		return false
+	}
+
+	lastFirst, syncedFirst := snapshot[fieldFirstName]
+	lastLast, syncedLast := snapshot[fieldLastName]
+	unedited := syncedFirst && syncedLast && identity.SameName(lastFirst, maDriver.FirstName) && identity.SameName(lastLast, maDriver.LastName)
+
+	return unedited || (known && sameFirst)
+}
+
+// nameSimilarity scores the Mike Albert driver's name against the ADP worker's legal name and,
+// when the worker has one, their preferred name, keeping the best
+func nameSimilarity(d adp.DriverHomeAddress, first, last string) float64 {
+	score := identity.NameSimilarity(d.FirstName, d.LastName, first, last)
+	if len(d.PreferredFirstName+d.PreferredLastName) == 0 {
+		return score
+	}
+
+	preferredFirst, preferredLast := d.FirstName, d.LastName
+	if len(d.PreferredFirstName) > 0 {
+		preferredFirst = d.PreferredFirstName
+	}
+	if len(d.PreferredLastName) > 0 {
+		preferredLast = d.PreferredLastName
+	}
+	return max(score, identity.NameSimilarity(preferredFirst, preferredLast, first, last))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/identity"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+)
+
+func TestNameChanged(t *testing.T) {
+	jane := mikealbert.Driver{FirstName: "Jane", LastName: "Smith"}
+	synced := map[string]string{fieldFirstName: "Jane", fieldLastName: "Smith"}
+
+	tests := []struct {
+		name     string
+		maDriver mikealbert.Driver
+		d        adp.DriverHomeAddress
+		snapshot map[string]string
+		known    bool
+		want     bool
+	}{
+		{"last name change, Mike Albert holds the synced name", jane, adp.DriverHomeAddress{FirstName: "Jane", LastName: "Doe"}, synced, false, true},
+		{"last name change, same employee number as last sync", jane, adp.DriverHomeAddress{FirstName: "Jane", LastName: "Doe"}, nil, true, true},
+		{"last name change, accents and case", jane, adp.DriverHomeAddress{FirstName: "JANE", LastName: "Doe-Núñez"}, synced, false, true},
+		{"last name change, preferred first name", jane, adp.DriverHomeAddress{FirstName: "Janet", PreferredFirstName: "Jane", LastName: "Doe"}, nil, true, true},
+		{"first name change, Mike Albert holds the synced name", jane, adp.DriverHomeAddress{FirstName: "Alex", LastName: "Smith"}, synced, false, true},
+		{"first name change, same employee number only", jane, adp.DriverHomeAddress{FirstName: "Alex", LastName: "Smith"}, nil, true, false},
+		{"new worker with the employee number", jane, adp.DriverHomeAddress{FirstName: "Jane", LastName: "Doe"}, nil, false, false},
+		{"Mike Albert edited since the last sync", mikealbert.Driver{FirstName: "Jane", LastName: "Smyth"}, adp.DriverHomeAddress{FirstName: "Jane", LastName: "Doe"}, synced, false, false},
+		{"different person", jane, adp.DriverHomeAddress{FirstName: "Robert", LastName: "Jones"}, synced, true, false},
+		{"no name in Mike Albert", mikealbert.Driver{}, adp.DriverHomeAddress{FirstName: "Jane", LastName: "Doe"}, nil, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameChanged(tt.maDriver, tt.d, tt.snapshot, tt.known); got != tt.want {
+				t.Errorf("nameChanged(%s %s, %s %s) = %v, want %v", tt.maDriver.FirstName, tt.maDriver.LastName, tt.d.FirstName, tt.d.LastName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastNameChangeScoresBelowThreshold(t *testing.T) {
+	// a new last name alone is not a plausible match, nameChanged is what accepts it
+	if score := identity.NameSimilarity("Jane", "Doe", "Jane", "Smith"); score >= 0.85 {
+		t.Errorf("NameSimilarity(Jane Doe, Jane Smith) = %.2f, expected below the default threshold", score)
+	}
+}
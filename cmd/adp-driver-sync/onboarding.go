@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// linkOnboarding is the source of a link created along with the Mike Albert driver
+const linkOnboarding = "onboarding"
+
+// newHire is an ADP fleet driver not found in Mike Albert
+type newHire struct {
+	Driver         adp.DriverHomeAddress
+	EmployeeNumber string
+	DriverId       int
+	Result         string
+}
+
+// checkOnboarding queues an ADP driver not found in Mike Albert for onboarding when they are marked
+// as a fleet driver, returning false when they are not
+func (run *syncRun) checkOnboarding(d adp.DriverHomeAddress, employeeNumber string) bool {
+	if !config.Onboarding.Enabled || !d.FleetDriver {
+		return false
+	}
+
+	log.Printf("  EmployeeNumber %s (%s %s) is a fleet driver not found in Mike Albert, queued for onboarding", employeeNumber, d.FirstName, d.LastName)
+	run.newHires = append(run.newHires, newHire{Driver: d, EmployeeNumber: employeeNumber, Result: "requested"})
+	return true
+}
+
+// createDrivers creates the queued new hires in Mike Albert when auto create is enabled, linking each
+// created driver to its ADP worker
+func (run *syncRun) createDrivers() {
+	if !config.Onboarding.AutoCreate {
+		run.summary.OnboardingRequested += len(run.newHires)
+		return
+	}
+
+	for i := range run.newHires {
+		hire := &run.newHires[i]
+		d := hire.Driver
+
+		employeeNumber := hire.EmployeeNumber
+		firstName, lastName := driverName(d)
+		created, err := run.mac.CreateDriver(mikealbert.Driver{
+			FirstName:      firstName,
+			LastName:       lastName,
+			EmployeeNumber: &employeeNumber,
+			Address:        outgoingAddress(d),
+		})
+		if err != nil || created.DriverId == nil {
+			log.Printf("  ERROR creating driver for EmployeeNumber %s: %+v", hire.EmployeeNumber, err)
+			hire.Result = "error"
+			run.summary.Errors++
+			continue
+		}
+
+		hire.DriverId = *created.DriverId
+		hire.Result = "created"
+		run.summary.Created++
+		log.Printf("  SUCCESS: Created DriverId %d for EmployeeNumber %s", hire.DriverId, hire.EmployeeNumber)
+
+		run.state.AddLink(linkKey(d), state.Link{
+			DriverId:       hire.DriverId,
+			EmployeeNumber: hire.EmployeeNumber,
+			Name:           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			Source:         linkOnboarding,
+		})
+		run.state.RecordSynced(hire.DriverId, addressFields(outgoingAddress(d)))
+	}
+}
+
+// writeOnboardingReport writes the fleet drivers queued for onboarding to the reports directory,
+// this is the onboarding request file when auto create is disabled
+func writeOnboardingReport(newHires []newHire) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(newHires))
+	for _, h := range newHires {
+		d := h.Driver
+		driverId := ""
+		if h.DriverId != 0 {
+			driverId = strconv.Itoa(h.DriverId)
+		}
+		a := outgoingAddress(d)
+		rows = append(rows, []string{h.EmployeeNumber, d.AssociateOID, d.FirstName, d.LastName, a.Address1, a.Address2, a.City, a.State, a.PostCode, a.Country, h.Result, driverId})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "onboarding", []string{"EmployeeNumber", "AssociateOID", "FirstName", "LastName", "Address1", "Address2", "City", "State", "PostCode", "Country", "Result", "DriverId"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote onboarding report %s", path)
+
+	return nil
+}
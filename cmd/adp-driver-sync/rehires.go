@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Actions for ADP workers rehired since the last sync
+const (
+	rehireReport     = "report"     // list in the rehires report only
+	rehireRelink     = "relink"     // link the worker to their prior Mike Albert driver
+	rehireReactivate = "reactivate" // link the worker to their prior Mike Albert driver and reactivate it
+)
+
+// linkRehire is the source of a link to a rehired worker's prior Mike Albert driver
+const linkRehire = "rehire"
+
+// How a rehired worker's prior Mike Albert driver was found
+const (
+	priorByLink           = "link"
+	priorByTermination    = "termination"
+	priorByEmployeeNumber = "employee number"
+)
+
+// rehire is an ADP worker rehired since the last sync
+type rehire struct {
+	Decision       adp.Decision
+	EmployeeNumber string // as stored in mike albert
+	PreviousStatus string
+	DriverId       int    // prior mike albert driver, 0 when not found
+	FoundBy        string // how the prior driver was found
+	Result         string
+}
+
+// isRehire checks if an ADP worker was rehired since the last sync, either by a status change from
+// terminated or by a rehire date after the worker was last seen
+func (run *syncRun) isRehire(d adp.Decision, previous state.Worker, seen bool) bool {
+	if isTerminated(d.Status) {
+		return false
+	}
+	if seen && isTerminated(previous.Status) {
+		return true
+	}
+	if len(d.RehireDate) == 0 {
+		return false
+	}
+
+	rehired, err := time.Parse("2006-01-02", d.RehireDate)
+	if err != nil {
+		log.Printf("  WARN: EmployeeNumber %s has invalid rehire date '%s'", d.EmployeeNumber, d.RehireDate)
+		return false
+	}
+
+	since := previous.Seen
+	if !seen {
+		if run.state.LastRun == nil {
+			return false
+		}
+		since = run.state.LastRun.Time
+	}
+	return rehired.After(since)
+}
+
+// detectRehire finds the prior Mike Albert driver of a worker rehired since the last sync, by link,
+// by the driver recorded at termination or by employee number, and relinks the worker to it as configured
+func (run *syncRun) detectRehire(d adp.Decision, key string, previous state.Worker) {
+	r := rehire{Decision: d, PreviousStatus: previous.Status}
+	if len(d.EmployeeNumber) > 0 {
+		r.EmployeeNumber = run.keys.Map(d.EmployeeNumber, d.CompanyCode)
+	}
+
+	if link, ok := run.state.Link(key); ok {
+		r.DriverId, r.FoundBy, r.Result = link.DriverId, priorByLink, "already linked"
+	} else if previous.DriverId > 0 {
+		r.DriverId, r.FoundBy = previous.DriverId, priorByTermination
+	} else if len(r.EmployeeNumber) > 0 {
+		maDrivers, err := run.mac.FindDrivers(r.EmployeeNumber)
+		verified, unverified := verifyByName(maDrivers, d)
+		switch {
+		case err != nil:
+			log.Printf("ERROR finding rehired driver %s in Mike Albert: %+v", r.EmployeeNumber, err)
+			run.summary.Errors++
+			r.Result = "error"
+		case len(verified) > 1:
+			r.Result = fmt.Sprintf("multiple drivers %s, not linked", driverIds(maDrivers))
+		case len(verified) == 1:
+			r.DriverId, r.FoundBy = verified[0], priorByEmployeeNumber
+		case len(unverified) > 0:
+			log.Printf("  WARN: EmployeeNumber %s (%s) rehired but %s, not linking it", d.EmployeeNumber, d.Name, strings.Join(unverified, "; "))
+			r.Result = "not verified, not linked: " + strings.Join(unverified, "; ")
+		}
+	}
+
+	if r.DriverId == 0 && len(r.Result) == 0 {
+		r.Result = "not found in Mike Albert"
+	}
+
+	// link the worker to the prior driver so this and later syncs update it
+	if r.DriverId > 0 && r.FoundBy != priorByLink && config.Rehires.Action != rehireReport {
+		run.state.AddLink(key, state.Link{
+			DriverId:       r.DriverId,
+			EmployeeNumber: r.EmployeeNumber,
+			Name:           d.Name,
+			Source:         linkRehire,
+		})
+		r.Result = "relinked"
+	}
+
+	log.Printf("  EmployeeNumber %s (%s) rehired since the last sync (status %s -> %s, rehired %s), prior Mike Albert driver %d",
+		d.EmployeeNumber, d.Name, previous.Status, d.Status, d.RehireDate, r.DriverId)
+	run.rehires = append(run.rehires, r)
+	run.summary.Rehired++
+}
+
+// applyRehires reactivates the prior Mike Albert driver of each rehired worker when configured to
+func (run *syncRun) applyRehires() {
+	for i := range run.rehires {
+		r := &run.rehires[i]
+
+		switch {
+		case r.DriverId == 0:
+			continue
+		case config.Rehires.Action == rehireReport:
+			r.Result = "reported"
+			continue
+		case config.Rehires.Action != rehireReactivate:
+			continue
+		}
+
+		maDriver, err := run.mac.GetDriver(r.DriverId)
+		if err != nil {
+			log.Printf("  ERROR reading DriverId %d for rehired EmployeeNumber %s: %+v", r.DriverId, r.EmployeeNumber, err)
+			r.Result = "error"
+			run.summary.Errors++
+			continue
+		}
+		if maDriver.IsActive() {
+			r.Result = "already active"
+			continue
+		}
+
+		// clear the termination date recorded when the worker left
+		active, terminationDate := true, ""
+		_, err = run.mac.UpdateDriver(r.DriverId, mikealbert.DriverUpdate{Active: &active, TerminationDate: &terminationDate})
+		if err != nil {
+			log.Printf("  ERROR reactivating DriverId %d for EmployeeNumber %s: %+v", r.DriverId, r.EmployeeNumber, err)
+			r.Result = "error"
+			run.summary.Errors++
+			continue
+		}
+
+		log.Printf("  SUCCESS: Reactivated DriverId %d for rehired EmployeeNumber %s", r.DriverId, r.EmployeeNumber)
+		r.Result = "reactivated"
+	}
+}
+
+// writeRehiresReport writes the workers rehired since the last sync to the reports directory
+func writeRehiresReport(rehires []rehire) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(rehires))
+	for _, r := range rehires {
+		driverId := ""
+		if r.DriverId > 0 {
+			driverId = strconv.Itoa(r.DriverId)
+		}
+		rows = append(rows, []string{r.Decision.EmployeeNumber, r.Decision.AssociateOID, r.Decision.Name, r.PreviousStatus, r.Decision.Status,
+			r.Decision.RehireDate, driverId, r.FoundBy, config.Rehires.Action, r.Result})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "rehires", []string{"EmployeeNumber", "AssociateOID", "Name", "PreviousStatus", "Status", "RehireDate", "DriverId", "FoundBy", "Action", "Result"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote rehires report %s", path)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+func TestDetectRehire(t *testing.T) {
+	config.Statuses.Actions = map[string]string{"A": adp.StatusSync, "L": adp.StatusSync, "T": adp.StatusTerminate}
+	config.Statuses.Default = adp.StatusSkip
+
+	lastRun := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		linked     bool          // worker linked to DriverId 42
+		previous   *state.Worker // nil when the worker was not seen by the last sync
+		status     string
+		rehireDate string
+		action     string
+		rehired    bool
+		foundBy    string
+		link       string // source of the worker's link afterwards, empty for none
+		firstRun   bool   // no sync has run before
+	}{
+		{"terminated to active, linked", true, &state.Worker{Status: "T"}, "A", "", rehireRelink, true, priorByLink, linkManual, false},
+		{"terminated to leave, driver recorded at termination", false, &state.Worker{Status: "T", DriverId: 42}, "L", "", rehireRelink, true, priorByTermination, linkRehire, false},
+		{"driver recorded at termination, report only", false, &state.Worker{Status: "T", DriverId: 42}, "A", "", rehireReport, true, priorByTermination, "", false},
+		{"terminated to active, no prior driver", false, &state.Worker{Status: "T"}, "A", "", rehireRelink, true, "", "", false},
+		{"rehire date after last seen", true, &state.Worker{Status: "A", Seen: lastRun}, "A", "2024-03-04", rehireRelink, true, priorByLink, linkManual, false},
+		{"rehire date before last seen", true, &state.Worker{Status: "A", Seen: lastRun}, "A", "2024-02-20", rehireRelink, false, "", linkManual, false},
+		{"rehire date after the last run when first seen", true, nil, "A", "2024-03-04", rehireRelink, true, priorByLink, linkManual, false},
+		{"rehire date with no last run", true, nil, "A", "2024-03-04", rehireRelink, false, "", linkManual, true},
+		{"invalid rehire date", true, &state.Worker{Status: "A", Seen: lastRun}, "A", "03/04/2024", rehireRelink, false, "", linkManual, false},
+		{"still terminated", true, &state.Worker{Status: "T"}, "T", "2024-03-04", rehireRelink, false, "", linkManual, false},
+		{"still active", true, &state.Worker{Status: "A", Seen: lastRun}, "A", "", rehireRelink, false, "", linkManual, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Rehires.Action = tt.action
+			run := &syncRun{state: &state.State{}}
+			if tt.linked {
+				run.state.AddLink("G3R1", state.Link{DriverId: 42, Source: linkManual})
+			}
+			if tt.previous != nil {
+				run.state.RecordWorker("G3R1", *tt.previous)
+			}
+			if !tt.firstRun {
+				run.state.LastRun = &state.Run{Time: lastRun}
+			}
+
+			run.detectStatusChanges([]adp.Decision{{AssociateOID: "G3R1", Name: "Ann Lee", Status: tt.status, RehireDate: tt.rehireDate}})
+			if got := len(run.rehires) == 1; got != tt.rehired {
+				t.Fatalf("detectStatusChanges() found %d rehires, want rehired %v", len(run.rehires), tt.rehired)
+			}
+			if tt.rehired && run.rehires[0].FoundBy != tt.foundBy {
+				t.Errorf("prior driver found by %q, want %q", run.rehires[0].FoundBy, tt.foundBy)
+			}
+			link, ok := run.state.Link("G3R1")
+			if link.Source != tt.link || (ok && link.DriverId != 42) {
+				t.Errorf("worker linked to DriverId %d by %q, want DriverId 42 by %q", link.DriverId, link.Source, tt.link)
+			}
+		})
+	}
+}
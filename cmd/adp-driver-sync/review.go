@@ -0,0 +1,534 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Kinds of items queued for review
+const (
+	reviewMultipleVehicles = "multiple-vehicles" // several vehicles allocated and none could be picked
+	reviewNameMismatch     = "name-mismatch"     // Mike Albert name does not match the ADP worker
+	reviewSuspicious       = "suspicious-address"
+	reviewMultipleMatches  = "multiple-matches" // employee number found several drivers and none was selected
+	reviewQuarantine       = "quarantine"       // address incomplete or invalid
+	reviewConflict         = "conflict"         // fields edited in Mike Albert held for manual resolution
+	reviewLinkNotFound     = "link-not-found"   // linked driver no longer found in Mike Albert
+)
+
+// Decisions a fleet admin makes on an exported review item
+const (
+	decisionApprove = "approve" // apply the proposed values
+	decisionReject  = "reject"  // leave Mike Albert as it is
+	decisionEdit    = "edit"    // apply the values as edited in the file
+)
+
+// linkReview is the source of links added by approving a review item
+const linkReview = "review"
+
+// reviewFields are the driver fields shown and editable in the review file, in column order
+var reviewFields = []string{fieldAddress1, fieldAddress2, fieldCity, fieldState, fieldPostCode, fieldCountry,
+	fieldFirstName, fieldLastName, fieldEmail, fieldMobilePhone, fieldHomePhone}
+
+// reviewColumn returns the review file column of a driver field, such as PostCode for postCode
+func reviewColumn(field string) string {
+	return strings.ToUpper(field[:1]) + field[1:]
+}
+
+// queueReview adds an item the sync could not safely handle to the review queue kept in the state file
+func (run *syncRun) queueReview(kind, key string, r state.Review) {
+	r.Kind = kind
+	run.state.QueueReview(kind+":"+key, r)
+}
+
+// queueWorkerReview queues an ADP driver not yet matched to a Mike Albert driver for review,
+// proposing their ADP address
+func (run *syncRun) queueWorkerReview(kind string, d adp.DriverHomeAddress, reason string) {
+	employeeNumber := run.keys.Map(d.EmployeeNumber, d.CompanyCode)
+	key := linkKey(d)
+	if len(key) == 0 {
+		key = employeeNumber
+	}
+
+	run.queueReview(kind, key, state.Review{
+		Worker:         linkKey(d),
+		EmployeeNumber: employeeNumber,
+		Name:           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+		Reason:         reason,
+		Proposed:       addressFields(outgoingAddress(d)),
+	})
+}
+
+// pendingReviews counts the items in the review queue waiting for a decision
+func pendingReviews(st *state.State) int {
+	pending := 0
+	for _, r := range st.Reviews {
+		if r.Status == state.ReviewPending {
+			pending++
+		}
+	}
+	return pending
+}
+
+// runReview exports the review queue for fleet admins and imports their decisions
+func runReview(args []string) error {
+	st, err := state.Load(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	switch {
+	case len(args) == 2 && args[0] == "export":
+		err = exportReviews(st, args[1])
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+		return nil
+
+	case len(args) == 2 && args[0] == "import":
+		err = importReviews(st, args[1])
+		if err != nil {
+			log.Printf("%+v", err)
+			return err
+		}
+
+	default:
+		err = fmt.Errorf("usage: review export <file> | review import <file>")
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = st.Save(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// exportReviews writes the items waiting for a decision to a CSV file for fleet admins to fill in
+// the Decision column, and DriverId or the field values where needed
+func exportReviews(st *state.State, file string) error {
+	ids := make([]string, 0, len(st.Reviews))
+	for id, r := range st.Reviews {
+		if r.Status == state.ReviewPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	header := []string{"ID", "Kind", "Created", "Worker", "EmployeeNumber", "Name", "Reason", "DriverId"}
+	for _, field := range reviewFields {
+		header = append(header, "MikeAlbert"+reviewColumn(field))
+	}
+	for _, field := range reviewFields {
+		header = append(header, reviewColumn(field))
+	}
+	header = append(header, "Decision")
+
+	rows := make([][]string, 0, len(ids))
+	for _, id := range ids {
+		r := st.Reviews[id]
+		driverId := ""
+		if r.DriverId != 0 {
+			driverId = strconv.Itoa(r.DriverId)
+		}
+
+		row := []string{id, r.Kind, r.Created.Format("2006-01-02"), r.Worker, r.EmployeeNumber, r.Name, r.Reason, driverId}
+		for _, field := range reviewFields {
+			row = append(row, r.Current[field])
+		}
+		for _, field := range reviewFields {
+			row = append(row, r.Proposed[field])
+		}
+		rows = append(rows, append(row, ""))
+	}
+
+	err := report.WriteFile(file, header, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	fmt.Printf("Exported %d review items to %s\n", len(rows), file)
+
+	return nil
+}
+
+// importReviews applies the decisions filled in to an exported review file. Rows without a
+// decision stay pending, as do rows that fail to apply.
+func importReviews(st *state.State, file string) error {
+	rows, err := report.Read(file)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	var run *syncRun
+	counts := make(map[string]int)
+	for _, row := range rows {
+		id := strings.TrimSpace(row["ID"])
+		decision := strings.ToLower(strings.TrimSpace(row["Decision"]))
+		if len(decision) == 0 {
+			continue
+		}
+
+		r, ok := st.Reviews[id]
+		switch {
+		case !ok:
+			log.Printf("WARN: review item %s is no longer queued, ignoring", id)
+			continue
+		case r.Status != state.ReviewPending:
+			log.Printf("WARN: review item %s was already %s, ignoring", id, r.Status)
+			continue
+		}
+
+		if len(strings.TrimSpace(row["DriverId"])) > 0 {
+			r.DriverId, err = strconv.Atoi(strings.TrimSpace(row["DriverId"]))
+			if err != nil {
+				log.Printf("ERROR review item %s has invalid Mike Albert driver ID '%s'", id, row["DriverId"])
+				counts["errors"]++
+				continue
+			}
+		}
+
+		var values map[string]string
+		switch decision {
+		case decisionReject:
+			st.DecideReview(id, state.ReviewRejected, "rejected")
+			log.Printf("Rejected %s", id)
+			counts[decision]++
+			continue
+		case decisionApprove:
+			values = r.Proposed
+			// suspicious addresses are synced anyway, approving only confirms the address
+			if r.Kind == reviewSuspicious {
+				values = nil
+			}
+		case decisionEdit:
+			values = editedValues(r, row)
+		default:
+			log.Printf("ERROR review item %s has unknown decision '%s', expected approve, reject or edit", id, row["Decision"])
+			counts["errors"]++
+			continue
+		}
+
+		// connect to mike albert on the first decision that needs it
+		if run == nil && (len(values) > 0 || r.DriverId != 0) {
+			run, err = reviewRun(st)
+			if err != nil {
+				log.Printf("%+v", err)
+				return err
+			}
+		}
+
+		note, err := run.applyReview(r, values)
+		if err != nil {
+			log.Printf("ERROR applying review item %s: %+v", id, err)
+			counts["errors"]++
+			continue
+		}
+		st.DecideReview(id, state.ReviewApproved, note)
+		log.Printf("Applied %s: %s", id, note)
+		counts[decision]++
+	}
+
+	fmt.Printf("Approved %d, edited %d, rejected %d, %d errors, %d items still pending\n",
+		counts[decisionApprove], counts[decisionEdit], counts[decisionReject], counts["errors"], pendingReviews(st))
+
+	return nil
+}
+
+// editedValues returns the field values of an edited review row, the fields proposed and any other
+// field given a value
+func editedValues(r state.Review, row map[string]string) map[string]string {
+	values := make(map[string]string)
+	for _, field := range reviewFields {
+		v, ok := row[reviewColumn(field)]
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if _, proposed := r.Proposed[field]; proposed || len(v) > 0 {
+			values[field] = v
+		}
+	}
+	return values
+}
+
+// reviewDriver returns the Mike Albert driver a review item applies to, set in the item, linked to
+// the worker or the single driver with the employee number
+func reviewDriver(mac *mikealbert.Client, st *state.State, r state.Review) (int, error) {
+	if r.DriverId != 0 {
+		return r.DriverId, nil
+	}
+	if link, ok := st.Link(r.Worker); ok {
+		return link.DriverId, nil
+	}
+
+	drivers, err := mac.FindDrivers(r.EmployeeNumber)
+	if err != nil {
+		log.Printf("%+v", err)
+		return 0, err
+	}
+	if len(drivers) != 1 || drivers[0].DriverId == nil {
+		err = fmt.Errorf("employee number %s found %d drivers in Mike Albert, set the DriverId", r.EmployeeNumber, len(drivers))
+		log.Printf("%+v", err)
+		return 0, err
+	}
+	return *drivers[0].DriverId, nil
+}
+
+// reviewRun sets up a sync run to send the decided review items through the same checks as a sync
+func reviewRun(st *state.State) (*syncRun, error) {
+	mac, err := newMikeAlbertClient()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	schema, err := driverSchema()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	cities, err := cityReference()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return &syncRun{mac: mac, cities: cities, schema: schema, state: st}, nil
+}
+
+// applyReview sends the values decided for a review item to its Mike Albert driver, returning what
+// was done. Approving a name mismatch, multiple match or link not found also links the worker to the driver so
+// later syncs update it.
+func (run *syncRun) applyReview(r state.Review, values map[string]string) (string, error) {
+	if len(values) == 0 && r.DriverId == 0 {
+		return "approved", nil
+	}
+
+	driverId, err := reviewDriver(run.mac, run.state, r)
+	if err != nil {
+		log.Printf("%+v", err)
+		return "", err
+	}
+
+	note := "approved"
+	if len(values) > 0 {
+		link, _ := run.state.Link(r.Worker)
+		maDriver, err := run.readDriver(driverId, r.EmployeeNumber, link.EmployeeNumber)
+		if err != nil {
+			log.Printf("%+v", err)
+			return "", err
+		}
+		if maDriver == nil {
+			err = fmt.Errorf("DriverId %d not found by employee number %s", driverId, r.EmployeeNumber)
+			log.Printf("%+v", err)
+			return "", err
+		}
+
+		changes, err := run.reviewedChanges(*maDriver, r, values)
+		if err != nil {
+			log.Printf("%+v", err)
+			return "", err
+		}
+
+		note = fmt.Sprintf("approved, DriverId %d already up to date", driverId)
+		if len(changes) > 0 {
+			note, err = run.updateReviewedDriver(driverId, maDriver.Address, changes)
+			if err != nil {
+				log.Printf("%+v", err)
+				return "", err
+			}
+		}
+	}
+
+	if (r.Kind == reviewNameMismatch || r.Kind == reviewMultipleMatches || r.Kind == reviewLinkNotFound) && len(r.Worker) > 0 {
+		run.state.AddLink(r.Worker, state.Link{
+			DriverId:       driverId,
+			EmployeeNumber: r.EmployeeNumber,
+			Name:           r.Name,
+			Source:         linkReview,
+		})
+		note += fmt.Sprintf(", linked %s to DriverId %d", r.Worker, driverId)
+	}
+
+	return note, nil
+}
+
+// reviewedChanges runs the values decided for a review item over the driver's Mike Albert values
+// through the same fitting, normalization and field policies as a sync, returning the changes to
+// send. A decided address is sent whole and refused when incomplete, invalid or not garageable.
+func (run *syncRun) reviewedChanges(maDriver mikealbert.Driver, r state.Review, values map[string]string) ([]fieldChange, error) {
+	d := adp.DriverHomeAddress{
+		EmployeeNumber: r.EmployeeNumber,
+		Address1:       maDriver.Address.Address1,
+		Address2:       maDriver.Address.Address2,
+		City:           maDriver.Address.City,
+		State:          maDriver.Address.State,
+		ZIPCode:        maDriver.Address.PostCode,
+		Country:        maDriver.Address.Country,
+		FirstName:      maDriver.FirstName,
+		LastName:       maDriver.LastName,
+		Email:          maDriver.Email,
+		MobilePhone:    maDriver.MobilePhone,
+		HomePhone:      maDriver.HomePhone,
+	}
+	decidedAddress := false
+	for field, v := range values {
+		switch field {
+		case fieldAddress1:
+			d.Address1 = v
+		case fieldAddress2:
+			d.Address2 = v
+		case fieldCity:
+			d.City = v
+		case fieldState:
+			d.State = v
+		case fieldPostCode:
+			d.ZIPCode = v
+		case fieldCountry:
+			d.Country = v
+		case fieldFirstName:
+			d.FirstName = v
+		case fieldLastName:
+			d.LastName = v
+		case fieldEmail:
+			d.Email = v
+		case fieldMobilePhone:
+			d.MobilePhone = v
+		case fieldHomePhone:
+			d.HomePhone = v
+		}
+		decidedAddress = decidedAddress || (!isNameField(field) && !isContactField(field))
+	}
+	d = run.fitDriver(d, r.EmployeeNumber)
+
+	var cs []component
+	if decidedAddress {
+		problems := incompleteAddress(d)
+		if len(problems) == 0 && config.Address.ValidatePostalCodes {
+			if v := address.Validate(d.Country, d.ZIPCode, d.State, d.City, run.cities); v.Result == address.Invalid {
+				problems = append(problems, v.Reason)
+			}
+		}
+		if len(problems) > 0 {
+			err := fmt.Errorf("address is incomplete or invalid (%s)", strings.Join(problems, ", "))
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		if kind := address.NonGarageable(d.Address1, d.Address2, d.City, d.State); len(kind) > 0 && config.Address.NonGarageablePolicy != policyFlag {
+			err := fmt.Errorf("address is a %s, a vehicle can't be garaged there", kind)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		cs = components(maDriver.Address, d)
+	}
+
+	for _, c := range nameComponents(maDriver, d) {
+		if _, ok := values[c.field]; !ok || len(c.incoming) == 0 {
+			continue
+		}
+		if !config.Names.Sync {
+			log.Printf("WARN: DriverId %d %s not sent, names are only sent with names sync", *maDriver.DriverId, c.field)
+			continue
+		}
+		cs = append(cs, c)
+	}
+
+	contacts, validate := contactComponents(maDriver, d)
+	for i, c := range contacts {
+		if _, ok := values[c.field]; !ok || len(strings.TrimSpace(c.incoming)) == 0 {
+			continue
+		}
+		if _, err := validate[i](c.incoming); err != nil {
+			err = fmt.Errorf("%s: %w", c.field, err)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+
+	return diffComponents(cs, d.Country, run.state.Snapshot(*maDriver.DriverId)), nil
+}
+
+// updateReviewedDriver sends reviewed changes to a Mike Albert driver, each field group on its own so a
+// value Mike Albert rejects doesn't lose the others. When Mike Albert won't update the address because
+// several vehicles are allocated, the admin's decision covers every vehicle, so all their garaging
+// addresses are updated instead.
+func (run *syncRun) updateReviewedDriver(driverId int, current mikealbert.Address, changes []fieldChange) (string, error) {
+	u := driverUpdate{DriverId: driverId, Current: current, Changes: changes}
+	note := fmt.Sprintf("updated DriverId %d", driverId)
+	for _, group := range fieldGroups {
+		groupChanges := u.group(group)
+		if len(groupChanges) == 0 {
+			continue
+		}
+
+		_, err := run.mac.UpdateDriver(driverId, buildUpdate(groupChanges))
+		if err == nil {
+			run.state.RecordSynced(driverId, sentFields(groupChanges))
+			continue
+		}
+		if group != groupAddress || !strings.Contains(err.Error(), "multiple vehicles allocated") {
+			log.Printf("%+v", err)
+			return "", err
+		}
+		if !run.mac.Unconfirmed {
+			err = fmt.Errorf("DriverId %d has multiple vehicles allocated, updating their garaging addresses needs Mike Albert UnconfirmedAPI", driverId)
+			log.Printf("%+v", err)
+			return "", err
+		}
+
+		note, err = run.garageReviewedAddress(driverId, current, groupChanges)
+		if err != nil {
+			log.Printf("%+v", err)
+			return "", err
+		}
+	}
+
+	return note, nil
+}
+
+// garageReviewedAddress updates the garaging address of every vehicle of a driver to a reviewed address
+func (run *syncRun) garageReviewedAddress(driverId int, current mikealbert.Address, changes []fieldChange) (string, error) {
+	target := updatedAddress(current, changes)
+	vehicles, err := run.mac.GetVehicleAllocations(driverId)
+	if err != nil {
+		log.Printf("%+v", err)
+		return "", err
+	}
+
+	garaging := fullAddressUpdate(target)
+	updated := 0
+	for _, v := range vehicles {
+		if sameAddress(v.GaragingAddress, target) {
+			continue
+		}
+		err = run.mac.UpdateGaragingAddress(v.UnitNo, garaging)
+		if err != nil {
+			log.Printf("%+v", err)
+			return "", err
+		}
+		updated++
+	}
+	// the driver's own address stays as it is, so later runs must not plan the same change again
+	run.state.RecordGaraging(driverId, state.Garaging{Home: addressFields(current), Target: addressFields(target)})
+
+	return fmt.Sprintf("updated DriverId %d and the garaging address of %d of %d vehicles", driverId, updated, len(vehicles)), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+func TestReviewedChanges(t *testing.T) {
+	driverId := 42
+	maDriver := mikealbert.Driver{
+		DriverId:  &driverId,
+		FirstName: "Jane",
+		LastName:  "Smith",
+		Address:   mikealbert.Address{Address1: "1 Main St", City: "Columbus", State: "OH", PostCode: "43215", Country: "US"},
+	}
+	home := map[string]string{fieldAddress1: "22 Oak Ave", fieldAddress2: "", fieldCity: "Dublin", fieldState: "OH", fieldPostCode: "43017-1234", fieldCountry: "US"}
+	with := func(field, value string) map[string]string {
+		values := make(map[string]string, len(home))
+		for f, v := range home {
+			values[f] = v
+		}
+		values[field] = value
+		return values
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		synced  map[string]string // last synced values, edited in Mike Albert since for a conflict
+		values  map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"new address, ZIP+4 truncated", "", nil, home, map[string]string{fieldAddress1: "22 Oak Ave", fieldCity: "Dublin", fieldPostCode: "43017"}, false},
+		{"address already in Mike Albert", "", nil, addressFields(maDriver.Address), map[string]string{}, false},
+		{"only a ZIP+4 suffix differs, nothing sent", "", nil, map[string]string{fieldPostCode: "43215-9999"}, map[string]string{}, false},
+		{"approved conflict on one field, rest of the address kept", reviewConflict, map[string]string{fieldAddress1: "3 Main St", fieldCity: "Columbus", fieldPostCode: "43215"},
+			map[string]string{fieldAddress1: "3 Main St"}, map[string]string{fieldAddress1: "3 Main St"}, false},
+		{"blank address line one", "", nil, with(fieldAddress1, ""), nil, true},
+		{"blank postal code", "", nil, with(fieldPostCode, ""), nil, true},
+		{"malformed postal code", "", nil, with(fieldPostCode, "4301"), nil, true},
+		{"PO box", "", nil, with(fieldAddress1, "PO Box 12"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &syncRun{schema: mikealbert.DefaultSchema(), state: &state.State{}}
+			run.state.RecordSynced(driverId, tt.synced)
+			changes, err := run.reviewedChanges(maDriver, state.Review{Kind: tt.kind, DriverId: driverId, EmployeeNumber: "1001"}, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reviewedChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := driverUpdate{Changes: changes}.sent()
+			if len(got) != len(tt.want) {
+				t.Fatalf("reviewedChanges() sent %v, want %v", got, tt.want)
+			}
+			for field, v := range tt.want {
+				if got[field] != v {
+					t.Errorf("reviewedChanges() %s = '%s', want '%s'", field, got[field], v)
+				}
+			}
+		})
+	}
+}
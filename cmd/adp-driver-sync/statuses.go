@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+)
+
+// statusCount is the number of ADP workers with an assignment status code and what the sync does with them
+type statusCount struct {
+	Status string
+	Action string
+	Count  int
+}
+
+// countStatuses counts the ADP workers by assignment status code, workers with no assignments are left out
+func countStatuses(decisions []adp.Decision) []statusCount {
+	counts := make(map[string]*statusCount)
+	for _, d := range decisions {
+		if len(d.StatusAction) == 0 {
+			continue
+		}
+		c, ok := counts[d.Status]
+		if !ok {
+			c = &statusCount{Status: d.Status, Action: d.StatusAction}
+			counts[d.Status] = c
+		}
+		c.Count++
+	}
+
+	statuses := make([]statusCount, 0, len(counts))
+	for _, c := range counts {
+		statuses = append(statuses, *c)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Status < statuses[j].Status })
+
+	return statuses
+}
+
+// heldWorkers returns the ADP workers held for review by their assignment status
+func heldWorkers(decisions []adp.Decision) []adp.Decision {
+	var held []adp.Decision
+	for _, d := range decisions {
+		if d.Rule == adp.RuleHeld {
+			held = append(held, d)
+		}
+	}
+	return held
+}
+
+// writeHeldReport writes the ADP workers held for review by their assignment status to the reports directory
+func writeHeldReport(held []adp.Decision) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(held))
+	for _, d := range held {
+		rows = append(rows, []string{d.EmployeeNumber, d.WorkerID, d.AssociateOID, d.Name, d.Status, d.TerminationDate})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "held", []string{"EmployeeNumber", "WorkerID", "AssociateOID", "Name", "Status", "TerminationDate"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote held report %s", path)
+
+	return nil
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/keymap"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// syncSummary counts the outcome of a sync run
+type syncSummary struct {
+	Workers   int
+	Drivers   int
+	Matched   int
+	Updated   int
+	Unchanged int
+	NotFound  int
+	Skipped   int
+	Conflicts int
+	Errors    int
+	Reviews   int
+
+	VehiclesUpdated int
+
+	Statuses []statusCount
+	Held     int
+
+	Created             int
+	OnboardingRequested int
+	Terminated          int
+	Rehired             int
+
+	Linked          int
+	NameMismatches  int
+	InvalidContacts int
+	MultipleMatches int
+
+	IncompleteSkipped     int
+	IncompleteWarned      int
+	IncompleteQuarantined int
+	NonGarageable         int
+	SuspiciousAddresses   int
+	Adjusted              int
+}
+
+// syncRun is what a single sync run works with and what it finds along the way
+type syncRun struct {
+	mac           *mikealbert.Client
+	keys          keymap.Mapper
+	cities        address.CityReference
+	schema        mikealbert.Schema
+	state         *state.State
+	summary       syncSummary
+	updates       []driverUpdate
+	quarantine    []quarantined
+	suspicious    []suspiciousAddress
+	adjustments   []adjustment
+	nonGarageable []nonGarageable
+	conflicts     []conflict
+	held          []adp.Decision
+	decisions     map[string]*adp.Decision // eligibility trace by worker, planning adds the rules it applies
+	knownWorkers  map[string]bool          // workers with the same employee number in the previous sync
+
+	nameMismatches  []nameMismatch
+	multipleMatches []multipleMatch
+	newHires        []newHire
+	terminations    []termination
+	rehires         []rehire
+	multiVehicles   []multiVehicle
+}
+
+// runSync syncs driver home addresses from ADP to Mike Albert, force overrides the guardrails
+func runSync(ac *adp.Client, force bool) error {
+	started := time.Now().UTC()
+
+	// create mike albert client
+	mac, err := newMikeAlbertClient()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// ADP to mike albert employee number mapping
+	keys, err := employeeNumberMapper()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// what mike albert accepts in each driver field
+	schema, err := driverSchema()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// reference cities by postal code for address validation
+	cities, err := cityReference()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// state from previous runs
+	st, err := state.Load(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// get employees from ADP
+	drivers, decisions, err := ac.GetDriverHomeAddresses(eligibilityRules())
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	log.Printf("Found %d drivers from ADP", len(drivers))
+
+	// work out what would change in mike albert
+	run := &syncRun{
+		mac:     mac,
+		keys:    keys,
+		cities:  cities,
+		schema:  schema,
+		state:   st,
+		summary: syncSummary{Workers: len(decisions), Drivers: len(drivers), Statuses: countStatuses(decisions)},
+		held:    heldWorkers(decisions),
+	}
+	run.summary.Held = len(run.held)
+	run.decisions = traceDecisions(decisions)
+	run.detectStatusChanges(decisions)
+	run.plan(drivers)
+
+	// written after planning, which excludes workers too
+	err = writeEligibilityReport(decisions)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = run.writeReviewReports()
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// stop before changing anything if the changes look like a bad ADP response
+	err = checkGuardrails(run.summary, run.plannedWrites(), st.LastRun)
+	if err != nil {
+		if !force {
+			for i := range run.updates {
+				run.updates[i].Result = "aborted"
+			}
+			if rerr := writeChangesReport(run.updates); rerr != nil {
+				log.Printf("%+v", rerr)
+			}
+			for i := range run.terminations {
+				run.terminations[i].Result = "aborted"
+			}
+			if rerr := writeOffboardingReport(run.terminations); rerr != nil {
+				log.Printf("%+v", rerr)
+			}
+			for i := range run.rehires {
+				run.rehires[i].Result = "aborted"
+			}
+			if rerr := writeRehiresReport(run.rehires); rerr != nil {
+				log.Printf("%+v", rerr)
+			}
+			log.Printf("ERROR sync aborted, no drivers updated: %+v (run with -force to override)", err)
+			return err
+		}
+		log.Printf("WARN: guardrails overridden with -force: %+v", err)
+	}
+
+	// update mike albert
+	run.apply()
+	run.createDrivers()
+	run.applyTerminations()
+	run.applyRehires()
+
+	err = writeChangesReport(run.updates)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeMultipleVehiclesReport(run.multiVehicles)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeAdjustmentsReport(run.adjustments)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeOnboardingReport(run.newHires)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeOffboardingReport(run.terminations)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeRehiresReport(run.rehires)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	// items no longer found by this run were fixed at the source or applied
+	pruned := st.PruneReviews(started)
+	if pruned > 0 {
+		log.Printf("Removed %d review items no longer found", pruned)
+	}
+	run.summary.Reviews = pendingReviews(st)
+
+	st.LastRun = &state.Run{
+		Time:     time.Now().UTC(),
+		Workers:  run.summary.Workers,
+		Eligible: run.summary.Drivers,
+		Matched:  run.summary.Matched,
+	}
+	err = st.Save(config.State.File)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	run.summary.log()
+
+	return nil
+}
+
+// writeReviewReports writes the reports of drivers held back for review
+func (run *syncRun) writeReviewReports() error {
+	err := writeQuarantineReport(run.quarantine)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeSuspiciousReport(run.suspicious)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeNonGarageableReport(run.nonGarageable)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeConflictsReport(run.conflicts)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeHeldReport(run.held)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeNameMismatchReport(run.nameMismatches)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = writeMultipleMatchReport(run.multipleMatches)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// log writes the run summary to the log
+func (summary syncSummary) log() {
+	log.Printf("=== SYNC COMPLETE ===")
+	log.Printf("  Total ADP workers:   %d", summary.Workers)
+	for _, s := range summary.Statuses {
+		log.Printf("    Status %-4s (%s): %d", s.Status, s.Action, s.Count)
+	}
+	log.Printf("  Total ADP drivers:   %d", summary.Drivers)
+	log.Printf("  Matched in MA:       %d (%d by link)", summary.Matched, summary.Linked)
+	log.Printf("  Updated:             %d", summary.Updated)
+	log.Printf("  Unchanged:           %d", summary.Unchanged)
+	log.Printf("  Not found in MA:     %d", summary.NotFound)
+	log.Printf("  Onboarding:          %d created, %d requested", summary.Created, summary.OnboardingRequested)
+	log.Printf("  Terminated:          %d", summary.Terminated)
+	log.Printf("  Rehired:             %d", summary.Rehired)
+	log.Printf("  Held (status):       %d", summary.Held)
+	log.Printf("  Multiple vehicles:   %d vehicles updated, %d drivers held for review", summary.VehiclesUpdated, summary.Skipped)
+	log.Printf("  Incomplete address:  %d skipped, %d quarantined, %d synced with warning", summary.IncompleteSkipped, summary.IncompleteQuarantined, summary.IncompleteWarned)
+	log.Printf("  Suspicious address:  %d", summary.SuspiciousAddresses)
+	log.Printf("  Non-garageable:      %d", summary.NonGarageable)
+	log.Printf("  Multiple matches:    %d", summary.MultipleMatches)
+	log.Printf("  Name mismatches:     %d", summary.NameMismatches)
+	log.Printf("  Invalid contacts:    %d", summary.InvalidContacts)
+	log.Printf("  Conflicts:           %d", summary.Conflicts)
+	log.Printf("  Adjusted for MA:     %d fields", summary.Adjusted)
+	log.Printf("  Pending review:      %d", summary.Reviews)
+	log.Printf("  Errors:              %d", summary.Errors)
+}
+
+// plan finds each ADP driver in Mike Albert and works out the address changes, without updating anything
+func (run *syncRun) plan(drivers []adp.DriverHomeAddress) {
+	for _, d := range drivers {
+		// employee number as stored in mike albert, by default without leading zeros
+		employeeNumber := run.keys.Map(d.EmployeeNumber, d.CompanyCode)
+
+		// never overwrite mike albert with a blank or malformed address
+		if !run.checkComplete(d) {
+			continue
+		}
+
+		// a vehicle can't be garaged at a PO box or other mail-only address
+		if !run.checkGarageable(d) {
+			continue
+		}
+
+		// fit the values to what mike albert accepts before comparing, so they compare equal once synced
+		d = run.fitDriver(d, employeeNumber)
+
+		// find the driver in mike albert, by link when there is one, otherwise by employee number
+		maDrivers, linked, err := run.findDrivers(d, employeeNumber)
+		if err != nil {
+			log.Printf("ERROR finding driver %s in Mike Albert: %+v", employeeNumber, err)
+			run.summary.Errors++
+			run.note(d, "mikeAlbertError", err.Error())
+			continue
+		}
+
+		// a linked driver not found is held for review, never onboarded again as a new driver
+		if len(maDrivers) == 0 && linked {
+			run.holdMissingLink(d, employeeNumber)
+			continue
+		}
+		if len(maDrivers) == 0 {
+			if run.checkOnboarding(d, employeeNumber) {
+				run.note(d, "onboarding", "requested")
+			} else {
+				run.summary.NotFound++
+				run.exclude(d, adp.RuleNotFound, "mikeAlbertEmployeeNumber", employeeNumber)
+			}
+			continue
+		}
+
+		// linked drivers were tied to the worker explicitly, no need to pick between or verify them
+		if linked {
+			run.summary.Linked++
+			run.summary.Matched++
+			run.planDriver(maDrivers[0], d, employeeNumber)
+			continue
+		}
+
+		// plan an update for each matching driver in mike albert
+		selected := run.disambiguate(maDrivers, d, employeeNumber)
+		if len(selected) == 0 {
+			run.exclude(d, adp.RuleMultipleMatches, "mikeAlbertDrivers", driverIds(maDrivers))
+			continue
+		}
+		verified := 0
+		for _, maDriver := range selected {
+			run.summary.Matched++
+			if !run.verifyIdentity(maDriver, d, employeeNumber) {
+				continue
+			}
+			verified++
+			run.planDriver(maDriver, d, employeeNumber)
+		}
+		if verified == 0 {
+			run.exclude(d, adp.RuleNameMismatch, "mikeAlbertDrivers", driverIds(selected))
+		}
+	}
+}
+
+// planDriver works out the address changes for a single Mike Albert driver
+func (run *syncRun) planDriver(maDriver mikealbert.Driver, d adp.DriverHomeAddress, employeeNumber string) {
+	driverId := *maDriver.DriverId
+
+	// a ZIP code belongs to a single state, so the same ZIP with different states means one side is wrong
+	if address.SamePostalCode(d.Country, maDriver.Address.PostCode, d.ZIPCode, false) && len(strings.TrimSpace(maDriver.Address.State)) > 0 && address.NormalizeState(maDriver.Address.State) != address.NormalizeState(d.State) {
+		log.Printf("  WARN: DriverId %d (%s) has postal code %s in both systems but state '%s' in Mike Albert and '%s' in ADP",
+			driverId, employeeNumber, d.ZIPCode, maDriver.Address.State, d.State)
+	}
+
+	// Compare current MA address with ADP address after normalization — only PATCH if different
+	snapshot := run.state.Snapshot(driverId)
+	changes := run.skipGaraged(driverId, maDriver.Address, diffAddress(maDriver.Address, d, snapshot))
+	changes = append(changes, diffName(maDriver, d, snapshot)...)
+	changes = append(changes, run.diffContact(maDriver, d, employeeNumber, snapshot)...)
+
+	// the fields already in agreement are the baseline for detecting later edits in mike albert
+	run.state.RecordSynced(driverId, matchingFields(maDriver.Address, d))
+	run.state.RecordSynced(driverId, matchingNameFields(maDriver, d))
+	run.state.RecordSynced(driverId, matchingContactFields(maDriver, d))
+
+	// hold back fields edited in mike albert since the last sync, as configured
+	changes = run.resolveConflicts(driverId, employeeNumber, changes)
+	run.note(d, "mikeAlbertDriver", fmt.Sprintf("%d, %d fields to change", driverId, len(changes)))
+	if len(changes) == 0 {
+		run.summary.Unchanged++
+		return
+	}
+
+	run.updates = append(run.updates, driverUpdate{
+		DriverId:       driverId,
+		EmployeeNumber: employeeNumber,
+		AddressSource:  d.AddressSource,
+		Current:        maDriver.Address,
+		Changes:        changes,
+	})
+}
+
+// apply sends the planned updates to Mike Albert, each field group on its own, recording the result
+// of each group
+func (run *syncRun) apply() {
+	for i := range run.updates {
+		update := &run.updates[i]
+
+		for _, c := range update.Changes {
+			log.Printf("  Updating DriverId %d (%s) %s: '%s' -> '%s'", update.DriverId, update.EmployeeNumber, c.Field, c.Current, c.Sent)
+		}
+
+		updated := false
+		for _, group := range fieldGroups {
+			if len(update.group(group)) > 0 && run.sendGroup(update, group) {
+				updated = true
+			}
+		}
+		if updated {
+			run.summary.Updated++
+		}
+	}
+}
+
+// sendGroup sends the changes of a driver update in a field group on their own, so a value Mike
+// Albert rejects doesn't lose the other groups. Reports whether the driver was updated.
+func (run *syncRun) sendGroup(update *driverUpdate, group string) bool {
+	changes := update.group(group)
+	_, err := run.mac.UpdateDriver(update.DriverId, buildUpdate(changes))
+	if err != nil {
+		if group == groupAddress && strings.Contains(err.Error(), "multiple vehicles allocated") {
+			log.Printf("  WARN: DriverId %d has multiple vehicles - updating vehicle garaging addresses", update.DriverId)
+			run.updateVehicles(update)
+			return false
+		}
+		log.Printf("  ERROR updating DriverId %d %s for EmployeeNumber %s: %+v", update.DriverId, group, update.EmployeeNumber, err)
+		update.setResult(group, "error")
+		run.summary.Errors++
+		return false
+	}
+
+	log.Printf("  SUCCESS: Updated DriverId %d %s", update.DriverId, group)
+	update.setResult(group, "updated")
+	run.state.RecordSynced(update.DriverId, sentFields(changes))
+	return true
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Actions for ADP workers terminated since the last sync
+const (
+	terminationReport     = "report"     // list in the offboarding report only
+	terminationFlag       = "flag"       // record the termination date on the Mike Albert driver
+	terminationInactivate = "inactivate" // record the termination date and inactivate the Mike Albert driver
+)
+
+// termination is an ADP worker terminated since the last sync
+type termination struct {
+	Decision       adp.Decision
+	EmployeeNumber string // as stored in mike albert
+	PreviousStatus string
+	DriverIds      []int
+	Unverified     []string // drivers found by employee number whose name does not match the worker
+	Result         string
+}
+
+// isTerminated checks if an ADP assignment status code is treated as a termination
+func isTerminated(status string) bool {
+	return eligibilityRules().Statuses.Action(status) == adp.StatusTerminate
+}
+
+// detectStatusChanges compares each ADP worker's status with the last sync, finding the workers
+// terminated or rehired since then. Every worker's status is recorded for the next sync.
+func (run *syncRun) detectStatusChanges(decisions []adp.Decision) {
+	for _, d := range decisions {
+		key := workerKey(d.AssociateOID, d.WorkerID)
+		if len(key) == 0 {
+			continue
+		}
+
+		previous, seen := run.state.Worker(key)
+		if seen && len(d.EmployeeNumber) > 0 && previous.EmployeeNumber == d.EmployeeNumber {
+			if run.knownWorkers == nil {
+				run.knownWorkers = make(map[string]bool)
+			}
+			run.knownWorkers[key] = true
+		}
+		current := state.Worker{Status: d.Status, EmployeeNumber: d.EmployeeNumber, DriverId: previous.DriverId}
+		switch {
+		case seen && !isTerminated(previous.Status) && isTerminated(d.Status):
+			if driverId := run.detectTermination(d, key, previous); driverId > 0 {
+				current.DriverId = driverId
+			}
+		case run.isRehire(d, previous, seen):
+			run.detectRehire(d, key, previous)
+		}
+		run.state.RecordWorker(key, current)
+	}
+}
+
+// detectTermination finds the Mike Albert drivers of a worker terminated since the last sync,
+// returning the driver when there is exactly one
+func (run *syncRun) detectTermination(d adp.Decision, key string, previous state.Worker) int {
+	t := termination{Decision: d, PreviousStatus: previous.Status}
+	if len(d.EmployeeNumber) > 0 {
+		t.EmployeeNumber = run.keys.Map(d.EmployeeNumber, d.CompanyCode)
+	}
+
+	// find the driver in mike albert, by link when there is one, otherwise by employee number
+	if link, ok := run.state.Link(key); ok {
+		t.DriverIds = []int{link.DriverId}
+	} else if len(t.EmployeeNumber) > 0 {
+		maDrivers, err := run.mac.FindDrivers(t.EmployeeNumber)
+		if err != nil {
+			log.Printf("ERROR finding terminated driver %s in Mike Albert: %+v", t.EmployeeNumber, err)
+			run.summary.Errors++
+		}
+		t.DriverIds, t.Unverified = verifyByName(maDrivers, d)
+	}
+
+	log.Printf("  EmployeeNumber %s (%s) terminated since the last sync (status %s -> %s, terminated %s), Mike Albert drivers %v",
+		d.EmployeeNumber, d.Name, previous.Status, d.Status, d.TerminationDate, t.DriverIds)
+	for _, u := range t.Unverified {
+		log.Printf("  WARN: EmployeeNumber %s (%s) terminated but %s, not offboarding it", d.EmployeeNumber, d.Name, u)
+	}
+	run.terminations = append(run.terminations, t)
+	run.summary.Terminated++
+
+	if len(t.DriverIds) == 1 {
+		return t.DriverIds[0]
+	}
+	return 0
+}
+
+// verifyByName picks the Mike Albert drivers a worker's employee number found by the multiple match
+// policy, then keeps those whose name matches the worker. Employee numbers are reused, so a driver is
+// never offboarded or relinked on the employee number alone, whatever identity.verifynames is.
+func verifyByName(maDrivers []mikealbert.Driver, d adp.Decision) ([]int, []string) {
+	worker := adp.DriverHomeAddress{EmployeeNumber: d.EmployeeNumber, FirstName: d.FirstName, LastName: d.LastName}
+
+	var verified []int
+	var unverified []string
+	for _, maDriver := range selectMatches(maDrivers, worker) {
+		score, named := nameScore(maDriver, worker)
+		switch {
+		case !named:
+			unverified = append(unverified, fmt.Sprintf("DriverId %d has no name to verify", *maDriver.DriverId))
+		case score < config.Identity.NameThreshold:
+			unverified = append(unverified, fmt.Sprintf("DriverId %d '%s %s' similarity %.2f", *maDriver.DriverId, maDriver.FirstName, maDriver.LastName, score))
+		default:
+			verified = append(verified, *maDriver.DriverId)
+		}
+	}
+	if len(maDrivers) > 1 && len(verified)+len(unverified) == 0 {
+		unverified = append(unverified, fmt.Sprintf("drivers %s, none selected by policy %s", driverIds(maDrivers), config.Identity.MultipleMatches))
+	}
+	return verified, unverified
+}
+
+// applyTerminations applies the configured termination action to the Mike Albert driver of each
+// terminated worker. Workers with no driver or several drivers are only reported.
+func (run *syncRun) applyTerminations() {
+	for i := range run.terminations {
+		t := &run.terminations[i]
+
+		switch {
+		case len(t.DriverIds) == 0 && len(t.Unverified) > 0:
+			t.Result = "not verified, not changed: " + strings.Join(t.Unverified, "; ")
+			continue
+		case len(t.DriverIds) == 0:
+			t.Result = "not found in Mike Albert"
+			continue
+		case len(t.DriverIds) > 1:
+			t.Result = "multiple drivers, not changed"
+			continue
+		case config.Terminations.Action == terminationReport:
+			t.Result = "reported"
+			continue
+		}
+
+		terminationDate := t.Decision.TerminationDate
+		if len(terminationDate) == 0 {
+			terminationDate = time.Now().Format("2006-01-02")
+		}
+		update := mikealbert.DriverUpdate{TerminationDate: &terminationDate}
+		if config.Terminations.Action == terminationInactivate {
+			active := false
+			update.Active = &active
+		}
+
+		_, err := run.mac.UpdateDriver(t.DriverIds[0], update)
+		if err != nil {
+			log.Printf("  ERROR recording termination on DriverId %d for EmployeeNumber %s: %+v", t.DriverIds[0], t.EmployeeNumber, err)
+			t.Result = "error"
+			run.summary.Errors++
+			continue
+		}
+
+		log.Printf("  SUCCESS: Recorded termination on DriverId %d (%s)", t.DriverIds[0], config.Terminations.Action)
+		if config.Terminations.Action == terminationInactivate {
+			t.Result = "inactivated"
+		} else {
+			t.Result = "flagged"
+		}
+	}
+}
+
+// writeOffboardingReport writes the workers terminated since the last sync to the reports directory
+func writeOffboardingReport(terminations []termination) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(terminations))
+	for _, t := range terminations {
+		ids := make([]string, 0, len(t.DriverIds))
+		for _, id := range t.DriverIds {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		rows = append(rows, []string{t.Decision.EmployeeNumber, t.Decision.AssociateOID, t.Decision.Name, t.PreviousStatus, t.Decision.Status,
+			t.Decision.TerminationDate, strings.Join(ids, " "), config.Terminations.Action, t.Result})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "offboarding", []string{"EmployeeNumber", "AssociateOID", "Name", "PreviousStatus", "Status", "TerminationDate", "DriverIds", "Action", "Result"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote offboarding report %s", path)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+func TestDetectTermination(t *testing.T) {
+	config.Statuses.Actions = map[string]string{"A": adp.StatusSync, "L": adp.StatusSync, "T": adp.StatusTerminate}
+	config.Statuses.Default = adp.StatusSkip
+
+	tests := []struct {
+		name       string
+		previous   *state.Worker // nil when the worker was not seen by the last sync
+		status     string
+		terminated bool
+		driverId   int // recorded with the worker for a later rehire
+	}{
+		{"active to terminated", &state.Worker{Status: "A"}, "T", true, 42},
+		{"leave to terminated", &state.Worker{Status: "L"}, "T", true, 42},
+		{"still terminated", &state.Worker{Status: "T", DriverId: 42}, "T", false, 42},
+		{"still active", &state.Worker{Status: "A"}, "A", false, 0},
+		{"active to leave", &state.Worker{Status: "A"}, "L", false, 0},
+		{"active to unmapped status", &state.Worker{Status: "A"}, "X", false, 0},
+		{"terminated when first seen", nil, "T", false, 0},
+		{"status code case", &state.Worker{Status: "a"}, "t", true, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &syncRun{state: &state.State{}}
+			run.state.AddLink("G3R1", state.Link{DriverId: 42, Source: linkManual})
+			if tt.previous != nil {
+				run.state.RecordWorker("G3R1", *tt.previous)
+			}
+
+			run.detectStatusChanges([]adp.Decision{{AssociateOID: "G3R1", Name: "Ann Lee", Status: tt.status}})
+			if got := len(run.terminations) == 1; got != tt.terminated {
+				t.Fatalf("detectStatusChanges() found %d terminations, want terminated %v", len(run.terminations), tt.terminated)
+			}
+			if tt.terminated && (len(run.terminations[0].DriverIds) != 1 || run.terminations[0].DriverIds[0] != 42) {
+				t.Errorf("termination drivers = %v, want [42] by link", run.terminations[0].DriverIds)
+			}
+			worker, _ := run.state.Worker("G3R1")
+			if worker.Status != tt.status || worker.DriverId != tt.driverId {
+				t.Errorf("recorded worker status %s, DriverId %d, want %s, %d", worker.Status, worker.DriverId, tt.status, tt.driverId)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+)
+
+// Policies for ADP addresses that fail validation
+const (
+	policySkip       = "skip"       // do not sync the driver
+	policyWarn       = "warn"       // log a warning and sync anyway
+	policyQuarantine = "quarantine" // do not sync the driver and list it in the quarantine report
+	policyFallback   = "fallback"   // sync another ADP address source, or skip the driver when there is none
+	policyFlag       = "flag"       // sync anyway and list the driver in a report for review
+)
+
+// suspiciousAddress is an ADP driver whose postal code could not be confirmed against the reference data
+type suspiciousAddress struct {
+	Driver adp.DriverHomeAddress
+	Reason string
+}
+
+// quarantined is an ADP driver held back from the sync because their address failed validation
+type quarantined struct {
+	Driver   adp.DriverHomeAddress
+	Problems []string
+}
+
+// incompleteAddress returns what is missing or malformed in an ADP address, nothing when complete
+func incompleteAddress(d adp.DriverHomeAddress) []string {
+	var problems []string
+
+	if len(strings.TrimSpace(d.Address1)) == 0 {
+		problems = append(problems, "address line one is blank")
+	}
+	if config.Address.RequireCity && len(strings.TrimSpace(d.City)) == 0 {
+		problems = append(problems, "city is blank")
+	}
+	if len(strings.TrimSpace(d.State)) == 0 {
+		problems = append(problems, "state is blank")
+	}
+	if len(strings.TrimSpace(d.ZIPCode)) == 0 {
+		problems = append(problems, "postal code is blank")
+	} else if _, err := address.NormalizePostalCode(d.Country, d.ZIPCode, config.MikeAlbert.PreserveZIP4); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return problems
+}
+
+// validateAddress checks a complete ADP address's postal code against its state and city offline,
+// returning the problem when the combination is impossible. Suspicious addresses are logged and
+// reported but still synced.
+func (run *syncRun) validateAddress(d adp.DriverHomeAddress) []string {
+	if !config.Address.ValidatePostalCodes {
+		return nil
+	}
+
+	v := address.Validate(d.Country, d.ZIPCode, d.State, d.City, run.cities)
+	switch v.Result {
+	case address.Invalid:
+		return []string{v.Reason}
+	case address.Suspicious:
+		log.Printf("  WARN: EmployeeNumber %s has a suspicious address (%s), syncing anyway", d.EmployeeNumber, v.Reason)
+		run.suspicious = append(run.suspicious, suspiciousAddress{Driver: d, Reason: v.Reason})
+		run.summary.SuspiciousAddresses++
+		run.note(d, "suspiciousAddress", v.Reason)
+		run.queueWorkerReview(reviewSuspicious, d, v.Reason)
+	}
+	return nil
+}
+
+// checkComplete applies the incomplete address policy to an ADP driver whose address is incomplete
+// or fails validation, returning false when the driver must not be synced
+func (run *syncRun) checkComplete(d adp.DriverHomeAddress) bool {
+	problems := incompleteAddress(d)
+	if len(problems) == 0 {
+		problems = run.validateAddress(d)
+	}
+	if len(problems) == 0 {
+		return true
+	}
+
+	switch config.Address.IncompletePolicy {
+	case policyWarn:
+		log.Printf("  WARN: EmployeeNumber %s has an incomplete or invalid address (%s), syncing anyway", d.EmployeeNumber, strings.Join(problems, ", "))
+		run.summary.IncompleteWarned++
+		run.note(d, "addressProblems", strings.Join(problems, "; "))
+		return true
+	case policyQuarantine:
+		log.Printf("  WARN: EmployeeNumber %s has an incomplete or invalid address (%s), quarantined", d.EmployeeNumber, strings.Join(problems, ", "))
+		run.quarantine = append(run.quarantine, quarantined{Driver: d, Problems: problems})
+		run.summary.IncompleteQuarantined++
+		run.queueWorkerReview(reviewQuarantine, d, strings.Join(problems, "; "))
+	default:
+		log.Printf("  WARN: EmployeeNumber %s has an incomplete or invalid address (%s), skipping", d.EmployeeNumber, strings.Join(problems, ", "))
+		run.summary.IncompleteSkipped++
+	}
+	run.exclude(d, adp.RuleIncompleteAddress, "addressProblems", strings.Join(problems, "; "))
+	return false
+}
+
+// writeSuspiciousReport writes the ADP drivers whose postal code could not be confirmed to the reports directory
+func writeSuspiciousReport(suspicious []suspiciousAddress) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(suspicious))
+	for _, s := range suspicious {
+		d := s.Driver
+		rows = append(rows, []string{d.EmployeeNumber, fmt.Sprintf("%s %s", d.FirstName, d.LastName), s.Reason,
+			d.Address1, d.Address2, d.City, d.State, d.ZIPCode, d.Country})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "suspicious-addresses", []string{"EmployeeNumber", "Name", "Reason", "Address1", "Address2", "City", "State", "PostCode", "Country"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote suspicious addresses report %s", path)
+
+	return nil
+}
+
+// cityReference loads the configured city reference for postal code validation, nil when there is none
+func cityReference() (address.CityReference, error) {
+	if !config.Address.ValidatePostalCodes || len(config.Address.CityReference) == 0 {
+		return nil, nil
+	}
+	return address.LoadCityReference(config.Address.CityReference)
+}
+
+// writeQuarantineReport writes the ADP drivers held back by validation to the reports directory
+func writeQuarantineReport(quarantine []quarantined) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(quarantine))
+	for _, q := range quarantine {
+		d := q.Driver
+		rows = append(rows, []string{d.EmployeeNumber, fmt.Sprintf("%s %s", d.FirstName, d.LastName), strings.Join(q.Problems, "; "),
+			d.Address1, d.Address2, d.City, d.State, d.ZIPCode, d.Country})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "quarantine", []string{"EmployeeNumber", "Name", "Problems", "Address1", "Address2", "City", "State", "PostCode", "Country"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote quarantine report %s", path)
+
+	return nil
+}
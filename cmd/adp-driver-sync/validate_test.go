@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/adp"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+)
+
+func TestIncompleteAddress(t *testing.T) {
+	complete := adp.DriverHomeAddress{Address1: "1 Main St", City: "Columbus", State: "OH", ZIPCode: "43215", Country: "US"}
+	with := func(change func(*adp.DriverHomeAddress)) adp.DriverHomeAddress {
+		d := complete
+		change(&d)
+		return d
+	}
+
+	tests := []struct {
+		name        string
+		d           adp.DriverHomeAddress
+		requireCity bool
+		problems    int
+	}{
+		{"complete", complete, true, 0},
+		{"blank line one", with(func(d *adp.DriverHomeAddress) { d.Address1 = " " }), false, 1},
+		{"blank state", with(func(d *adp.DriverHomeAddress) { d.State = "" }), false, 1},
+		{"blank postal code", with(func(d *adp.DriverHomeAddress) { d.ZIPCode = "" }), false, 1},
+		{"malformed postal code", with(func(d *adp.DriverHomeAddress) { d.ZIPCode = "4321" }), false, 1},
+		{"blank city allowed", with(func(d *adp.DriverHomeAddress) { d.City = "" }), false, 0},
+		{"blank city required", with(func(d *adp.DriverHomeAddress) { d.City = "" }), true, 1},
+		{"nothing entered", adp.DriverHomeAddress{Country: "US"}, true, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Address.RequireCity = tt.requireCity
+			defer func() { config.Address.RequireCity = false }()
+
+			if got := incompleteAddress(tt.d); len(got) != tt.problems {
+				t.Errorf("incompleteAddress() = %v, want %d problems", got, tt.problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/config"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/mikealbert"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/report"
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/state"
+)
+
+// Policies for drivers whose address Mike Albert won't update because several vehicles are allocated to them
+const (
+	vehiclesHome    = "home"    // update the vehicles garaged at the driver's Mike Albert home address
+	vehiclesAll     = "all"     // update every vehicle
+	vehiclesPrimary = "primary" // update the vehicle marked primary, otherwise the most recently allocated
+	vehiclesReview  = "review"  // update none and list the driver for review
+)
+
+// multiVehicle is a driver with several vehicles allocated and what was done with their garaging addresses
+type multiVehicle struct {
+	DriverId       int
+	EmployeeNumber string
+	Vehicles       []mikealbert.VehicleAllocation
+	Updated        []string // unit numbers updated
+	Review         bool     // could not be resolved automatically
+	Result         string
+}
+
+// sameAddress compares two Mike Albert addresses after normalization
+func sameAddress(a, b mikealbert.Address) bool {
+	return address.SameLine(a.Address1, b.Address1) &&
+		address.SameLine(a.Address2, b.Address2) &&
+		address.NormalizeCity(a.City) == address.NormalizeCity(b.City) &&
+		address.NormalizeState(a.State) == address.NormalizeState(b.State) &&
+		address.SamePostalCode(b.Country, a.PostCode, b.PostCode, config.MikeAlbert.PreserveZIP4)
+}
+
+// updatedAddress returns the Mike Albert address with the changed components applied
+func updatedAddress(current mikealbert.Address, changes []fieldChange) mikealbert.Address {
+	a := current
+	for _, c := range changes {
+		switch c.Field {
+		case fieldAddress1:
+			a.Address1 = c.Sent
+		case fieldAddress2:
+			a.Address2 = c.Sent
+		case fieldCity:
+			a.City = c.Sent
+		case fieldState:
+			a.State = c.Sent
+		case fieldPostCode:
+			a.PostCode = c.Sent
+		case fieldCountry:
+			a.Country = c.Sent
+		}
+	}
+	return a
+}
+
+// fieldsAddress returns the Mike Albert address of fields by component, the reverse of addressFields
+func fieldsAddress(fields map[string]string) mikealbert.Address {
+	return mikealbert.Address{
+		Address1: fields[fieldAddress1],
+		Address2: fields[fieldAddress2],
+		City:     fields[fieldCity],
+		State:    fields[fieldState],
+		PostCode: fields[fieldPostCode],
+		Country:  fields[fieldCountry],
+	}
+}
+
+// skipGaraged drops the address changes of a driver whose vehicles an earlier run garaged at the new
+// address because Mike Albert would not update the driver's own address, as long as neither the
+// driver's Mike Albert address nor the new address changed since. Names and contact details are kept.
+func (run *syncRun) skipGaraged(driverId int, current mikealbert.Address, changes []fieldChange) []fieldChange {
+	g, ok := run.state.Garaging(driverId)
+	if !ok || !sameAddress(current, fieldsAddress(g.Home)) || !sameAddress(updatedAddress(current, changes), fieldsAddress(g.Target)) {
+		return changes
+	}
+
+	var keep []fieldChange
+	for _, c := range changes {
+		if isNameField(c.Field) || isContactField(c.Field) {
+			keep = append(keep, c)
+		}
+	}
+	return keep
+}
+
+// fullAddressUpdate returns an update setting every component of an address
+func fullAddressUpdate(a mikealbert.Address) mikealbert.AddressUpdate {
+	return mikealbert.AddressUpdate{
+		Address1: &a.Address1,
+		Address2: &a.Address2,
+		City:     &a.City,
+		State:    &a.State,
+		PostCode: &a.PostCode,
+		Country:  &a.Country,
+	}
+}
+
+// selectVehicles picks the vehicles whose garaging address follows the driver's home address by
+// the configured policy, returning why when none can be picked
+func selectVehicles(vehicles []mikealbert.VehicleAllocation, home, target mikealbert.Address) ([]mikealbert.VehicleAllocation, string) {
+	switch config.MultipleVehicles.Policy {
+	case vehiclesAll:
+		return vehicles, ""
+
+	case vehiclesPrimary:
+		var primary []mikealbert.VehicleAllocation
+		for _, v := range vehicles {
+			if v.IsPrimary() {
+				primary = append(primary, v)
+			}
+		}
+		if len(primary) == 1 {
+			return primary, ""
+		}
+
+		// otherwise the most recently allocated, allocation dates are ISO 8601 so they sort as strings
+		var newest []mikealbert.VehicleAllocation
+		for _, v := range vehicles {
+			switch {
+			case len(v.AllocationDate) == 0:
+			case len(newest) == 0 || v.AllocationDate > newest[0].AllocationDate:
+				newest = []mikealbert.VehicleAllocation{v}
+			case v.AllocationDate == newest[0].AllocationDate:
+				newest = append(newest, v)
+			}
+		}
+		if len(newest) == 1 {
+			return newest, ""
+		}
+		return nil, "no single primary or most recently allocated vehicle"
+
+	case vehiclesHome:
+		// vehicles already moved to the new address by an earlier run count as garaged at home
+		var atHome []mikealbert.VehicleAllocation
+		for _, v := range vehicles {
+			if sameAddress(v.GaragingAddress, home) || sameAddress(v.GaragingAddress, target) {
+				atHome = append(atHome, v)
+			}
+		}
+		if len(atHome) > 0 {
+			return atHome, ""
+		}
+		return nil, "no vehicle garaged at the driver's home address"
+	}
+
+	return nil, "multiple vehicles policy is review"
+}
+
+// updateVehicles updates the garaging address of the vehicles of a driver Mike Albert would not
+// update because several vehicles are allocated to them, recording the result on the address changes.
+// Without the unconfirmed vehicle endpoints the policy is review, so the driver is held for review
+// without listing their vehicles.
+func (run *syncRun) updateVehicles(update *driverUpdate) {
+	mv := multiVehicle{DriverId: update.DriverId, EmployeeNumber: update.EmployeeNumber}
+	defer func() { run.multiVehicles = append(run.multiVehicles, mv) }()
+
+	target := updatedAddress(update.Current, update.Changes)
+	allocated := "several vehicles allocated"
+	reason := "multiple vehicles policy is review"
+	var selected []mikealbert.VehicleAllocation
+	if run.mac.Unconfirmed {
+		vehicles, err := run.mac.GetVehicleAllocations(update.DriverId)
+		if err != nil {
+			log.Printf("  ERROR listing vehicles of DriverId %d for EmployeeNumber %s: %+v", update.DriverId, update.EmployeeNumber, err)
+			mv.Result = "error listing vehicles"
+			update.setResult(groupAddress, "error")
+			run.summary.Errors++
+			return
+		}
+		mv.Vehicles = vehicles
+		allocated = fmt.Sprintf("%d vehicles allocated", len(vehicles))
+		selected, reason = selectVehicles(vehicles, update.Current, target)
+	}
+
+	if len(selected) == 0 {
+		log.Printf("  WARN: DriverId %d has multiple vehicles - %s, holding for review", update.DriverId, reason)
+		mv.Review, mv.Result = true, reason
+		update.setResult(groupAddress, "skipped, multiple vehicles, queued for review")
+		run.summary.Skipped++
+		run.queueReview(reviewMultipleVehicles, strconv.Itoa(update.DriverId), state.Review{
+			DriverId:       update.DriverId,
+			EmployeeNumber: update.EmployeeNumber,
+			Reason:         fmt.Sprintf("%s, %s", allocated, reason),
+			Current:        addressFields(update.Current),
+			Proposed:       addressFields(target),
+		})
+		return
+	}
+
+	garaging := fullAddressUpdate(target)
+	failed := 0
+	for _, v := range selected {
+		if sameAddress(v.GaragingAddress, target) {
+			continue
+		}
+		err := run.mac.UpdateGaragingAddress(v.UnitNo, garaging)
+		if err != nil {
+			log.Printf("  ERROR updating garaging address of unit %s for DriverId %d: %+v", v.UnitNo, update.DriverId, err)
+			failed++
+			run.summary.Errors++
+			continue
+		}
+		log.Printf("  SUCCESS: Updated garaging address of unit %s for DriverId %d", v.UnitNo, update.DriverId)
+		mv.Updated = append(mv.Updated, v.UnitNo)
+		run.summary.VehiclesUpdated++
+	}
+
+	mv.Result = fmt.Sprintf("updated %d of %d vehicles", len(mv.Updated), len(mv.Vehicles))
+	if failed > 0 {
+		mv.Result += fmt.Sprintf(", %d failed", failed)
+	} else {
+		// the driver's own address stays as it is, so later runs must not plan the same change again
+		run.state.RecordGaraging(update.DriverId, state.Garaging{Home: addressFields(update.Current), Target: addressFields(target)})
+	}
+	update.setResult(groupAddress, "vehicles "+mv.Result)
+}
+
+// writeMultipleVehiclesReport writes the drivers with several vehicles allocated to the reports directory
+func writeMultipleVehiclesReport(multiVehicles []multiVehicle) error {
+	if len(config.Reports.Directory) == 0 {
+		return nil
+	}
+
+	rows := make([][]string, 0, len(multiVehicles))
+	for _, mv := range multiVehicles {
+		vehicles := make([]string, 0, len(mv.Vehicles))
+		for _, v := range mv.Vehicles {
+			a := v.GaragingAddress
+			vehicles = append(vehicles, fmt.Sprintf("%s (%s, %s %s %s)", v.UnitNo, a.Address1, a.City, a.State, a.PostCode))
+		}
+		rows = append(rows, []string{strconv.Itoa(mv.DriverId), mv.EmployeeNumber, strings.Join(vehicles, "; "), strings.Join(mv.Updated, " "),
+			config.MultipleVehicles.Policy, strconv.FormatBool(mv.Review), mv.Result})
+	}
+
+	path, err := report.Write(config.Reports.Directory, "multiple-vehicles", []string{"DriverId", "EmployeeNumber", "Vehicles", "Updated", "Policy", "Review", "Result"}, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	log.Printf("Wrote multiple vehicles report %s", path)
+
+	return nil
+}
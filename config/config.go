@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -12,13 +13,56 @@ var (
 	msgMissingField = "required configuration missing %s"
 
 	// unwrapped config values
-	Adp        adp
-	MikeAlbert mikealbert
+	Adp              adp
+	MikeAlbert       mikealbert
+	Reports          reports
+	Address          addressRules
+	State            state
+	Guardrails       guardrails
+	Fields           map[string]fieldPolicy
+	Conflicts        conflicts
+	Identity         identity
+	EmployeeNumber   employeeNumber
+	Onboarding       onboarding
+	Terminations     terminations
+	Rehires          rehires
+	Statuses         statuses
+	Names            names
+	Contact          contact
+	MultipleVehicles multipleVehicles
 )
 
 type configuration struct {
-	Adp        adp
-	MikeAlbert mikealbert
+	Adp              adp
+	MikeAlbert       mikealbert
+	Reports          reports
+	Address          addressRules
+	State            state
+	Guardrails       guardrails
+	Fields           map[string]fieldPolicy
+	Conflicts        conflicts
+	Identity         identity
+	EmployeeNumber   employeeNumber
+	Onboarding       onboarding
+	Terminations     terminations
+	Rehires          rehires
+	Statuses         statuses
+	Names            names
+	Contact          contact
+	MultipleVehicles multipleVehicles
+}
+
+func (c *configuration) setDefaults() {
+	c.Adp.OptOut.setDefaults()
+	c.State.setDefaults()
+	c.Address.setDefaults()
+	c.Conflicts.setDefaults()
+	c.Onboarding.setDefaults()
+	c.Terminations.setDefaults()
+	c.Rehires.setDefaults()
+	c.Names.setDefaults()
+	c.MultipleVehicles.setDefaults(c.MikeAlbert.UnconfirmedAPI)
+	c.Statuses.setDefaults()
 }
 
 func (c *configuration) validate() error {
@@ -28,15 +72,116 @@ func (c *configuration) validate() error {
 	if err := c.MikeAlbert.validate(); err != nil {
 		return err
 	}
+	if err := c.Address.validate(); err != nil {
+		return err
+	}
+	if err := c.Guardrails.validate(); err != nil {
+		return err
+	}
+	if err := c.Conflicts.validate(); err != nil {
+		return err
+	}
+	if err := c.Identity.validate(); err != nil {
+		return err
+	}
+	if err := c.EmployeeNumber.validate(); err != nil {
+		return err
+	}
+	if err := c.Onboarding.validate(); err != nil {
+		return err
+	}
+	if err := c.Terminations.validate(); err != nil {
+		return err
+	}
+	if err := c.Rehires.validate(); err != nil {
+		return err
+	}
+	if err := c.Names.validate(); err != nil {
+		return err
+	}
+	if err := c.MultipleVehicles.validate(); err != nil {
+		return err
+	}
+	if err := c.Statuses.validate(); err != nil {
+		return err
+	}
+	if err := c.checkUnconfirmed(); err != nil {
+		return err
+	}
+	if c.Onboarding.Enabled && !c.Onboarding.AutoCreate && len(c.Reports.Directory) == 0 {
+		return fmt.Errorf(msgMissingField, "Reports Directory, needed for onboarding requests")
+	}
+	for name, f := range c.Fields {
+		if err := f.validate(name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// checkUnconfirmed rejects settings that write through Mike Albert endpoints or fields not yet
+// confirmed against the API unless they are allowed
+func (c *configuration) checkUnconfirmed() error {
+	if c.MikeAlbert.UnconfirmedAPI {
+		return nil
+	}
+
+	var setting string
+	switch {
+	case c.Onboarding.Enabled && c.Onboarding.AutoCreate:
+		setting = "Onboarding AutoCreate"
+	case c.Terminations.Action != "report":
+		setting = "Terminations Action " + c.Terminations.Action
+	case c.Rehires.Action == "reactivate":
+		setting = "Rehires Action reactivate"
+	case c.Names.Sync:
+		setting = "Names Sync"
+	case c.Contact.Email || c.Contact.MobilePhone || c.Contact.HomePhone:
+		setting = "Contact"
+	case c.MultipleVehicles.Policy != "review":
+		setting = "MultipleVehicles Policy " + c.MultipleVehicles.Policy
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s needs Mike Albert UnconfirmedAPI, its endpoints are not yet confirmed against the Mike Albert API", setting)
+}
+
 type adp struct {
 	ClientId     string
 	ClientSecret string
 	BaseURL      string
 	CertFile     string
 	KeyFile      string
+	OptOut       optOut
+}
+
+// optOut describes the ADP custom field workers use to opt out of the sync
+type optOut struct {
+	Fields       []string
+	Match        string
+	OptOutValues []string
+	OptInValues  []string
+}
+
+func (o *optOut) setDefaults() {
+	if len(o.Fields) == 0 {
+		o.Fields = []string{"OVERDRIVE SYNC"}
+	}
+	if len(o.Match) == 0 {
+		o.Match = "normalized"
+	}
+	if len(o.OptOutValues) == 0 {
+		o.OptOutValues = []string{"No"}
+	}
+}
+
+func (o *optOut) validate() error {
+	switch o.Match {
+	case "exact", "normalized", "contains":
+	default:
+		return fmt.Errorf("ADP OptOut Match must be one of exact, normalized or contains, got '%s'", o.Match)
+	}
+	return nil
 }
 
 func (a *adp) validate() error {
@@ -55,6 +200,9 @@ func (a *adp) validate() error {
 	if len(a.KeyFile) == 0 {
 		return fmt.Errorf("ADP KeyFile is required")
 	}
+	if err := a.OptOut.validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -62,6 +210,12 @@ type mikealbert struct {
 	ClientId     string
 	ClientSecret string
 	Endpoint     string
+	PreserveZIP4 bool
+	FieldLimits  map[string]int // most characters Mike Albert accepts, by driver field, overriding the built in limits
+
+	// UnconfirmedAPI allows the endpoints and driver fields not yet confirmed against the Mike Albert API,
+	// listed in the README
+	UnconfirmedAPI bool
 }
 
 func (m *mikealbert) validate() error {
@@ -80,7 +234,379 @@ func (m *mikealbert) validate() error {
 		log.Printf("%+v", err)
 		return err
 	}
+	for field, limit := range m.FieldLimits {
+		if limit <= 0 {
+			err := fmt.Errorf("Mike Albert FieldLimits %s must be positive", field)
+			log.Printf("%+v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reports controls where run reports are written, reports are skipped when Directory is empty
+type reports struct {
+	Directory string
+}
+
+// addressRules control how ADP addresses are compared with and written to Mike Albert
+type addressRules struct {
+	NormalizeWrites  bool
+	IncompletePolicy string   // skip, warn or quarantine
+	RequireCity      bool     // treat an address without a city as incomplete
+	Sources          []string // legal, mailing, other or worklocation, in order of preference
+	// NonGarageablePolicy is skip, fallback or flag, for PO boxes and other addresses a vehicle can't be garaged at
+	NonGarageablePolicy string
+	ValidatePostalCodes bool   // check postal codes against the state, and city with a CityReference, offline
+	CityReference       string // optional CSV file of postal code, city pairs
+}
+
+// defaultAddress is set before reading the configuration file so postal code validation can be turned off
+func defaultAddress() addressRules {
+	return addressRules{
+		ValidatePostalCodes: true,
+	}
+}
+
+func (a *addressRules) setDefaults() {
+	if len(a.IncompletePolicy) == 0 {
+		a.IncompletePolicy = "skip"
+	}
+	if len(a.Sources) == 0 {
+		a.Sources = []string{"legal"}
+	}
+	if len(a.NonGarageablePolicy) == 0 {
+		a.NonGarageablePolicy = "skip"
+	}
+}
+
+func (a *addressRules) validate() error {
+	switch a.IncompletePolicy {
+	case "skip", "warn", "quarantine":
+	default:
+		return fmt.Errorf("Address IncompletePolicy must be one of skip, warn or quarantine, got '%s'", a.IncompletePolicy)
+	}
+	for _, source := range a.Sources {
+		switch source {
+		case "legal", "mailing", "other", "worklocation":
+		default:
+			return fmt.Errorf("Address Sources must be legal, mailing, other or worklocation, got '%s'", source)
+		}
+	}
+	switch a.NonGarageablePolicy {
+	case "skip", "fallback", "flag":
+	default:
+		return fmt.Errorf("Address NonGarageablePolicy must be one of skip, fallback or flag, got '%s'", a.NonGarageablePolicy)
+	}
+	return nil
+}
+
+// state is where data kept between sync runs is stored
+type state struct {
+	File string
+}
+
+func (s *state) setDefaults() {
+	if len(s.File) == 0 {
+		s.File = "adp-driver-sync.state.json"
+	}
+}
+
+// guardrails abort a sync before anything is updated when the changes look like a bad ADP response,
+// a limit of 0 disables that check
+type guardrails struct {
+	MaxChanges         int     // most drivers that may be updated in one run
+	MaxChangePercent   float64 // most percent of matched drivers that may be updated in one run
+	MinWorkerPercent   float64 // least percent of the previous run's ADP workers that must be returned
+	MinEligiblePercent float64 // least percent of the previous run's eligible workers that must be eligible
+}
+
+// defaultGuardrails are set before reading the configuration file so an explicit 0 disables a check
+func defaultGuardrails() guardrails {
+	return guardrails{
+		MaxChangePercent:   20,
+		MinWorkerPercent:   80,
+		MinEligiblePercent: 80,
+	}
+}
+
+func (g *guardrails) validate() error {
+	if g.MaxChanges < 0 {
+		return fmt.Errorf("Guardrails MaxChanges cannot be negative")
+	}
+	for name, pct := range map[string]float64{"MaxChangePercent": g.MaxChangePercent, "MinWorkerPercent": g.MinWorkerPercent, "MinEligiblePercent": g.MinEligiblePercent} {
+		if pct < 0 || pct > 100 {
+			return fmt.Errorf("Guardrails %s must be between 0 and 100", name)
+		}
+	}
+	return nil
+}
+
+// fieldPolicy controls how a single Mike Albert driver field is updated from ADP
+type fieldPolicy struct {
+	Update string // always (default), fillempty or never
+	Clear  string // allowed (default) or forbidden, whether a blank ADP value may clear the field
+}
+
+func (f *fieldPolicy) validate(name string) error {
+	switch f.Update {
+	case "", "always", "fillempty", "never":
+	default:
+		return fmt.Errorf("Fields %s Update must be one of always, fillempty or never, got '%s'", name, f.Update)
+	}
+	switch f.Clear {
+	case "", "allowed", "forbidden":
+	default:
+		return fmt.Errorf("Fields %s Clear must be one of allowed or forbidden, got '%s'", name, f.Clear)
+	}
+	return nil
+}
+
+// Field returns the update policy for a field, fields not configured are always updated and may be cleared
+func Field(name string) fieldPolicy {
+	f := Fields[strings.ToLower(name)]
+	if len(f.Update) == 0 {
+		f.Update = "always"
+	}
+	if len(f.Clear) == 0 {
+		f.Clear = "allowed"
+	}
+	return f
+}
+
+// conflicts controls fields edited in Mike Albert since the last sync
+type conflicts struct {
+	Resolution string // adp, mikealbert or manual
+}
+
+func (c *conflicts) setDefaults() {
+	if len(c.Resolution) == 0 {
+		c.Resolution = "manual"
+	}
+}
+
+func (c *conflicts) validate() error {
+	switch c.Resolution {
+	case "adp", "mikealbert", "manual":
+	default:
+		return fmt.Errorf("Conflicts Resolution must be one of adp, mikealbert or manual, got '%s'", c.Resolution)
+	}
+	return nil
+}
+
+// identity controls verifying that a Mike Albert driver found by employee number is the same person as in ADP
+type identity struct {
+	VerifyNames     bool
+	NameThreshold   float64 // least name similarity, from 0 to 1, to accept a match
+	MultipleMatches string  // all, active, none or name, which drivers to update when an employee number finds several
+}
+
+// defaultIdentity is set before reading the configuration file so an explicit threshold of 0 and
+// name verification can be turned off. Employee numbers finding several drivers are held for review by default.
+func defaultIdentity() identity {
+	return identity{
+		VerifyNames:     true,
+		NameThreshold:   0.85,
+		MultipleMatches: "none",
+	}
+}
 
+func (i *identity) validate() error {
+	if i.NameThreshold < 0 || i.NameThreshold > 1 {
+		return fmt.Errorf("Identity NameThreshold must be between 0 and 1")
+	}
+	switch i.MultipleMatches {
+	case "all", "active", "none", "name":
+	default:
+		return fmt.Errorf("Identity MultipleMatches must be one of all, active, none or name, got '%s'", i.MultipleMatches)
+	}
+	return nil
+}
+
+// employeeNumber controls how ADP workers are joined to Mike Albert drivers by employee number
+type employeeNumber struct {
+	Source          string            // payrollFileNumber, workerId, associateOID or custom
+	CustomField     string            // custom field code or short name when Source is custom
+	StripZeros      bool              // remove leading zeros
+	PadLength       int               // left pad with zeros to this length, 0 for no padding
+	CompanyPrefixes map[string]string // prefix added by ADP company code
+	OverridesFile   string            // CSV of ADP employee number, Mike Albert employee number exceptions
+}
+
+// defaultEmployeeNumber is set before reading the configuration file so leading zeros can be kept
+func defaultEmployeeNumber() employeeNumber {
+	return employeeNumber{
+		Source:     "payrollFileNumber",
+		StripZeros: true,
+	}
+}
+
+func (e *employeeNumber) validate() error {
+	switch e.Source {
+	case "payrollFileNumber", "workerId", "associateOID":
+	case "custom":
+		if len(e.CustomField) == 0 {
+			return fmt.Errorf(msgMissingField, "EmployeeNumber CustomField")
+		}
+	default:
+		return fmt.Errorf("EmployeeNumber Source must be one of payrollFileNumber, workerId, associateOID or custom, got '%s'", e.Source)
+	}
+	if e.PadLength < 0 {
+		return fmt.Errorf("EmployeeNumber PadLength cannot be negative")
+	}
+	return nil
+}
+
+// onboarding controls creating Mike Albert drivers for new ADP hires marked as fleet drivers
+type onboarding struct {
+	Enabled    bool
+	AutoCreate bool     // create the driver in Mike Albert, otherwise write an onboarding request
+	Fields     []string // custom field codes or short names marking a fleet driver
+	Values     []string // values marking a fleet driver
+}
+
+func (o *onboarding) setDefaults() {
+	if len(o.Values) == 0 {
+		o.Values = []string{"Yes"}
+	}
+}
+
+func (o *onboarding) validate() error {
+	if o.Enabled && len(o.Fields) == 0 {
+		return fmt.Errorf(msgMissingField, "Onboarding Fields")
+	}
+	return nil
+}
+
+// terminations controls what happens in Mike Albert to ADP workers terminated since the last sync
+type terminations struct {
+	Action string // report, flag or inactivate
+}
+
+func (t *terminations) setDefaults() {
+	if len(t.Action) == 0 {
+		t.Action = "report"
+	}
+}
+
+func (t *terminations) validate() error {
+	switch t.Action {
+	case "report", "flag", "inactivate":
+	default:
+		return fmt.Errorf("Terminations Action must be one of report, flag or inactivate, got '%s'", t.Action)
+	}
+	return nil
+}
+
+// rehires is what to do with the Mike Albert driver of an ADP worker rehired since the last sync
+type rehires struct {
+	Action string // report, relink or reactivate
+}
+
+func (r *rehires) setDefaults() {
+	if len(r.Action) == 0 {
+		r.Action = "relink"
+	}
+}
+
+func (r *rehires) validate() error {
+	switch r.Action {
+	case "report", "relink", "reactivate":
+	default:
+		return fmt.Errorf("Rehires Action must be one of report, relink or reactivate, got '%s'", r.Action)
+	}
+	return nil
+}
+
+// names controls syncing driver names from ADP to Mike Albert
+type names struct {
+	Sync   bool
+	Source string // legal or preferred, preferred falls back to the legal name
+}
+
+func (n *names) setDefaults() {
+	if len(n.Source) == 0 {
+		n.Source = "legal"
+	}
+}
+
+func (n *names) validate() error {
+	switch n.Source {
+	case "legal", "preferred":
+	default:
+		return fmt.Errorf("Names Source must be one of legal or preferred, got '%s'", n.Source)
+	}
+	return nil
+}
+
+// contact enables syncing each driver contact detail from ADP to Mike Albert
+type contact struct {
+	Email         bool // personal email address
+	UnmarkedEmail bool // sync the first email address when none is marked personal
+	MobilePhone   bool
+	HomePhone     bool // landline
+}
+
+// multipleVehicles controls updating the garaging address of drivers with several vehicles allocated
+type multipleVehicles struct {
+	Policy string // home, all, primary or review
+}
+
+// setDefaults defaults the policy to home when the vehicle endpoints may be used, otherwise to review
+func (m *multipleVehicles) setDefaults(unconfirmed bool) {
+	if len(m.Policy) == 0 && unconfirmed {
+		m.Policy = "home"
+	}
+	if len(m.Policy) == 0 {
+		m.Policy = "review"
+	}
+}
+
+func (m *multipleVehicles) validate() error {
+	switch m.Policy {
+	case "home", "all", "primary", "review":
+	default:
+		return fmt.Errorf("MultipleVehicles Policy must be one of home, all, primary or review, got '%s'", m.Policy)
+	}
+	return nil
+}
+
+// statuses maps ADP assignment status codes to what the sync does with the worker
+type statuses struct {
+	Actions map[string]string // sync, skip, terminate or hold by status code
+	Default string            // action for status codes not listed
+}
+
+func (s *statuses) setDefaults() {
+	if len(s.Actions) == 0 {
+		s.Actions = map[string]string{"A": "sync", "L": "sync", "T": "terminate"}
+	}
+	if len(s.Default) == 0 {
+		s.Default = "skip"
+	}
+
+	// ADP status codes are uppercase
+	actions := make(map[string]string, len(s.Actions))
+	for code, action := range s.Actions {
+		actions[strings.ToUpper(strings.TrimSpace(code))] = action
+	}
+	s.Actions = actions
+}
+
+func (s *statuses) validate() error {
+	for code, action := range s.Actions {
+		switch action {
+		case "sync", "skip", "terminate", "hold":
+		default:
+			return fmt.Errorf("Statuses Actions %s must be one of sync, skip, terminate or hold, got '%s'", code, action)
+		}
+	}
+	switch s.Default {
+	case "sync", "skip", "terminate", "hold":
+	default:
+		return fmt.Errorf("Statuses Default must be one of sync, skip, terminate or hold, got '%s'", s.Default)
+	}
 	return nil
 }
 
@@ -93,13 +619,21 @@ func FromFile(configFile string) error {
 		return err
 	}
 
-	var c configuration
+	c := configuration{
+		Address:        defaultAddress(),
+		Guardrails:     defaultGuardrails(),
+		Identity:       defaultIdentity(),
+		EmployeeNumber: defaultEmployeeNumber(),
+	}
 	err = yaml.Unmarshal(bytes, &c)
 	if err != nil {
 		log.Printf("%+v", err)
 		return err
 	}
 
+	// defaults for optional settings
+	c.setDefaults()
+
 	// validation
 	err = c.validate()
 	if err != nil {
@@ -109,6 +643,24 @@ func FromFile(configFile string) error {
 
 	Adp = c.Adp
 	MikeAlbert = c.MikeAlbert
+	Reports = c.Reports
+	Address = c.Address
+	State = c.State
+	Guardrails = c.Guardrails
+	Conflicts = c.Conflicts
+	Identity = c.Identity
+	EmployeeNumber = c.EmployeeNumber
+	Onboarding = c.Onboarding
+	Terminations = c.Terminations
+	Rehires = c.Rehires
+	Names = c.Names
+	Contact = c.Contact
+	MultipleVehicles = c.MultipleVehicles
+	Statuses = c.Statuses
+	Fields = make(map[string]fieldPolicy, len(c.Fields))
+	for name, f := range c.Fields {
+		Fields[strings.ToLower(name)] = f
+	}
 
 	return nil
 }
@@ -117,8 +669,23 @@ func FromFile(configFile string) error {
 func Write(configFile string) error {
 	// wrap
 	c := configuration{
-		Adp:        Adp,
-		MikeAlbert: MikeAlbert,
+		Adp:              Adp,
+		MikeAlbert:       MikeAlbert,
+		Reports:          Reports,
+		Address:          Address,
+		State:            State,
+		Guardrails:       Guardrails,
+		Fields:           Fields,
+		Conflicts:        Conflicts,
+		Identity:         Identity,
+		EmployeeNumber:   EmployeeNumber,
+		Onboarding:       Onboarding,
+		Terminations:     Terminations,
+		Rehires:          Rehires,
+		Names:            Names,
+		Contact:          Contact,
+		MultipleVehicles: MultipleVehicles,
+		Statuses:         Statuses,
 	}
 
 	// make sure valid before proceeding
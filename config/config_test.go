@@ -0,0 +1,62 @@
+package config
+
+import "testing"
+
+func TestStatusesDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses statuses
+		code     string
+		action   string
+	}{
+		{"active synced by default", statuses{}, "A", "sync"},
+		{"leave synced by default", statuses{}, "L", "sync"},
+		{"terminated by default", statuses{}, "T", "terminate"},
+		{"configured codes replace the defaults", statuses{Actions: map[string]string{"A": "sync"}}, "L", ""},
+		{"configured codes uppercased", statuses{Actions: map[string]string{" l ": "hold"}}, "L", "hold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.statuses
+			s.setDefaults()
+			if got := s.Actions[tt.code]; got != tt.action {
+				t.Errorf("Actions[%s] = %q, want %q", tt.code, got, tt.action)
+			}
+			if s.Default != "skip" {
+				t.Errorf("Default = %q, want skip", s.Default)
+			}
+		})
+	}
+}
+
+func TestMultipleVehiclesUnconfirmed(t *testing.T) {
+	tests := []struct {
+		name        string
+		unconfirmed bool
+		policy      string
+		want        string // policy after defaults
+		rejected    bool
+	}{
+		{"default with the unconfirmed API", true, "", "home", false},
+		{"default without the unconfirmed API", false, "", "review", false},
+		{"review without the unconfirmed API", false, "review", "review", false},
+		{"home without the unconfirmed API", false, "home", "home", true},
+		{"all without the unconfirmed API", false, "all", "all", true},
+		{"primary with the unconfirmed API", true, "primary", "primary", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := configuration{MultipleVehicles: multipleVehicles{Policy: tt.policy}, Terminations: terminations{Action: "report"}}
+			c.MikeAlbert.UnconfirmedAPI = tt.unconfirmed
+			c.MultipleVehicles.setDefaults(tt.unconfirmed)
+			if c.MultipleVehicles.Policy != tt.want {
+				t.Errorf("Policy = %s, want %s", c.MultipleVehicles.Policy, tt.want)
+			}
+			if err := c.checkUnconfirmed(); (err != nil) != tt.rejected {
+				t.Errorf("checkUnconfirmed() = %v, want rejected %v", err, tt.rejected)
+			}
+		})
+	}
+}
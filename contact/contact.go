@@ -0,0 +1,77 @@
+package contact
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+)
+
+// dialingCodes are the country calling codes assumed for phone numbers without one, by country
+var dialingCodes = map[string]string{
+	address.CountryUS: "1",
+	address.CountryCA: "1",
+	address.CountryMX: "52",
+}
+
+// NormalizePhone returns a phone number in E.164 format (+16145551234). Numbers without a country
+// calling code get the code of country, the country of the driver's address. An error is returned
+// when the number can't be a valid phone number.
+func NormalizePhone(country, number string) (string, error) {
+	number = strings.TrimSpace(number)
+	international := strings.HasPrefix(number, "+") || strings.HasPrefix(number, "00")
+
+	var digits strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" ()-./+", r):
+		default:
+			return "", fmt.Errorf("invalid phone number '%s'", number)
+		}
+	}
+	d := digits.String()
+	if strings.HasPrefix(number, "00") {
+		d = d[2:]
+	}
+
+	if !international {
+		code, ok := dialingCodes[address.Country(country)]
+		switch {
+		case !ok:
+			return "", fmt.Errorf("phone number '%s' has no country calling code", number)
+		case code == "1" && len(d) == 11 && d[0] == '1':
+			d = d[1:] // 1 614 555 1234
+		}
+		if code == "1" && len(d) != 10 {
+			return "", fmt.Errorf("invalid phone number '%s'", number)
+		}
+		d = code + d
+	}
+
+	// E.164 allows at most 15 digits including the country calling code, which never starts with 0
+	if len(d) < 8 || len(d) > 15 || d[0] == '0' {
+		return "", fmt.Errorf("invalid phone number '%s'", number)
+	}
+	return "+" + d, nil
+}
+
+// NormalizeEmail returns an email address trimmed and lowercased. An error is returned when it is
+// not a plain address with a local part and a domain.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(addr.Name) > 0 {
+		return "", fmt.Errorf("invalid email address '%s'", email)
+	}
+
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("invalid email address '%s'", email)
+	}
+	return email, nil
+}
@@ -0,0 +1,70 @@
+package contact
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+		number  string
+		want    string
+		wantErr bool
+	}{
+		{"US formatted", "US", "(614) 555-1234", "+16145551234", false},
+		{"US dotted", "USA", "614.555.1234", "+16145551234", false},
+		{"US with leading 1", "US", "1-614-555-1234", "+16145551234", false},
+		{"blank country is US", "", "6145551234", "+16145551234", false},
+		{"international", "US", "+1 614 555 1234", "+16145551234", false},
+		{"international 00 prefix", "US", "0044 20 7946 0958", "+442079460958", false},
+		{"other country international", "GB", "+44 20 7946 0958", "+442079460958", false},
+		{"Canada", "CA", "416-555-0199", "+14165550199", false},
+		{"Mexico", "MX", "55 1234 5678", "+525512345678", false},
+		{"US too short", "US", "555-1234", "", true},
+		{"US too long", "US", "614-555-12345", "", true},
+		{"extension", "US", "614-555-1234 x123", "", true},
+		{"extension spelled out", "US", "614-555-1234 ext. 123", "", true},
+		{"letters", "US", "614-CALL-NOW", "", true},
+		{"other country without calling code", "GB", "020 7946 0958", "", true},
+		{"international too long", "US", "+1234567890123456", "", true},
+		{"blank", "US", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.country, tt.number)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePhone(%q, %q) error = %v, want error %v", tt.country, tt.number, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhone(%q, %q) = %q, want %q", tt.country, tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email   string
+		want    string
+		wantErr bool
+	}{
+		{" Jane.Smith@Example.COM ", "jane.smith@example.com", false},
+		{"jane+fleet@mail.example.co.uk", "jane+fleet@mail.example.co.uk", false},
+		{"Jane Smith <jane@example.com>", "", true},
+		{"jane@localhost", "", true},
+		{"jane@example.", "", true},
+		{"jane.example.com", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeEmail(tt.email)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeEmail(%q) error = %v, want error %v", tt.email, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
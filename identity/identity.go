@@ -0,0 +1,113 @@
+package identity
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/MikeAlbertFleetSolutions/adp-driver-sync/address"
+)
+
+// NormalizeName folds a name to ASCII, uppercases it and keeps only letters and single spaces
+func NormalizeName(name string) string {
+	name = strings.ToUpper(address.Fold(name))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r):
+			return r
+		case r == '\'' || r == '.':
+			return -1 // O'NEIL -> ONEIL, J. -> J
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// SameName compares two names ignoring case, diacritics, punctuation and spacing
+func SameName(a, b string) bool {
+	return NormalizeName(a) == NormalizeName(b)
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, from 0 (nothing alike) to 1 (equal)
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	window = max(window, 0)
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	// boost for a common prefix of up to 4 characters
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// firstNameSimilarity compares first names, treating an initial or a shortened name as a strong match
+// (J = JOHN, CHRIS = CHRISTOPHER)
+func firstNameSimilarity(a, b string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	score := jaroWinkler(a, b)
+	if (len(a) == 1 || len(b) == 1) && a[0] == b[0] {
+		score = max(score, 0.9)
+	}
+	if len(a) >= 3 && len(b) >= 3 && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+		score = max(score, 0.9)
+	}
+	return score
+}
+
+// NameSimilarity scores how plausibly two people are the same person by name, from 0 to 1.
+// Last names weigh more than first names, and a first and last name entered the wrong way around
+// still match.
+func NameSimilarity(firstA, lastA, firstB, lastB string) float64 {
+	firstA, lastA = NormalizeName(firstA), NormalizeName(lastA)
+	firstB, lastB = NormalizeName(firstB), NormalizeName(lastB)
+
+	score := func(fa, la, fb, lb string) float64 {
+		return 0.4*firstNameSimilarity(fa, fb) + 0.6*jaroWinkler(la, lb)
+	}
+
+	return max(score(firstA, lastA, firstB, lastB), score(firstA, lastA, lastB, firstB))
+}
@@ -0,0 +1,84 @@
+package identity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"MARTHA", "MARHTA", 0.961},
+		{"DWAYNE", "DUANE", 0.840},
+		{"DIXON", "DICKSONX", 0.813},
+		{"SMITH", "SMITH", 1},
+		{"", "SMITH", 0},
+		{"ABC", "XYZ", 0},
+	}
+
+	for _, tt := range tests {
+		if got := jaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"José", "JOSE"},
+		{"O'Neil", "ONEIL"},
+		{"J.", "J"},
+		{"Mary-Jane  Smith", "MARY JANE SMITH"},
+		{"  müller ", "MULLER"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeName(tt.name); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	const threshold = 0.85
+
+	tests := []struct {
+		name                         string
+		firstA, lastA, firstB, lastB string
+		match                        bool
+	}{
+		{"same", "John", "Smith", "John", "Smith", true},
+		{"case and accents", "José", "García", "JOSE", "GARCIA", true},
+		{"punctuation", "Shane", "O'Neil", "Shane", "ONeil", true},
+		{"typo", "Jonathan", "Smith", "Johnathan", "Smith", true},
+		{"initial", "J", "Smith", "John", "Smith", true},
+		{"shortened first name", "Chris", "Johnson", "Christopher", "Johnson", true},
+		{"swapped first and last", "Smith", "John", "John", "Smith", true},
+		{"swapped with accents", "García", "José", "Jose", "Garcia", true},
+		{"different person", "John", "Smith", "Maria", "Gonzalez", false},
+		{"same first name only", "John", "Smith", "John", "Williams", false},
+		{"same last name only", "John", "Smith", "Mary", "Smith", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := NameSimilarity(tt.firstA, tt.lastA, tt.firstB, tt.lastB)
+			if (score >= threshold) != tt.match {
+				t.Errorf("NameSimilarity(%q %q, %q %q) = %.2f, want match %v", tt.firstA, tt.lastA, tt.firstB, tt.lastB, score, tt.match)
+			}
+		})
+	}
+}
+
+func TestNameSimilaritySymmetric(t *testing.T) {
+	a := NameSimilarity("Chris", "Johnson", "Christopher", "Johnsen")
+	b := NameSimilarity("Christopher", "Johnsen", "Chris", "Johnson")
+	if math.Abs(a-b) > 1e-9 {
+		t.Errorf("NameSimilarity is not symmetric: %.4f and %.4f", a, b)
+	}
+}
@@ -0,0 +1,77 @@
+package keymap
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// Mapper turns an ADP employee number into the employee number Mike Albert stores
+type Mapper struct {
+	StripZeros      bool              // remove leading zeros
+	PadLength       int               // left pad with zeros to this length, 0 for no padding
+	CompanyPrefixes map[string]string // prefix added by ADP company code
+	Overrides       map[string]string // explicit Mike Albert employee number by ADP employee number, applied before any transformation
+}
+
+// Map returns the Mike Albert employee number for an ADP employee number and company code
+func (m Mapper) Map(employeeNumber, companyCode string) string {
+	employeeNumber = strings.TrimSpace(employeeNumber)
+	if override, ok := m.Overrides[employeeNumber]; ok {
+		return override
+	}
+
+	if m.StripZeros {
+		employeeNumber = strings.TrimLeft(employeeNumber, "0")
+	}
+	if m.PadLength > 0 && len(employeeNumber) < m.PadLength {
+		employeeNumber = strings.Repeat("0", m.PadLength-len(employeeNumber)) + employeeNumber
+	}
+
+	return m.CompanyPrefixes[strings.TrimSpace(companyCode)] + employeeNumber
+}
+
+// LoadOverrides reads an override file of ADP employee number, Mike Albert employee number pairs,
+// one pair per line in CSV format. A header line is skipped when present.
+func LoadOverrides(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	overrides := make(map[string]string)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("%+v", err)
+			return nil, err
+		}
+
+		adpNumber, maNumber := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if line == 1 && strings.EqualFold(adpNumber, "adp") {
+			continue
+		}
+		if len(adpNumber) == 0 || len(maNumber) == 0 {
+			err = fmt.Errorf("%s line %d: both employee numbers are required", file, line)
+			log.Printf("%+v", err)
+			return nil, err
+		}
+		overrides[adpNumber] = maNumber
+	}
+
+	return overrides, nil
+}
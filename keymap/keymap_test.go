@@ -0,0 +1,74 @@
+package keymap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		name           string
+		m              Mapper
+		employeeNumber string
+		companyCode    string
+		want           string
+	}{
+		{"unchanged by default", Mapper{}, " 001234 ", "ABC", "001234"},
+		{"strip zeros", Mapper{StripZeros: true}, "001234", "", "1234"},
+		{"strip all zeros", Mapper{StripZeros: true}, "000", "", ""},
+		{"pad", Mapper{PadLength: 6}, "1234", "", "001234"},
+		{"pad leaves longer numbers", Mapper{PadLength: 4}, "123456", "", "123456"},
+		{"strip then pad", Mapper{StripZeros: true, PadLength: 8}, "0001234", "", "00001234"},
+		{"company prefix", Mapper{CompanyPrefixes: map[string]string{"ABC": "A-"}}, "1234", " ABC ", "A-1234"},
+		{"company without a prefix", Mapper{CompanyPrefixes: map[string]string{"ABC": "A-"}}, "1234", "XYZ", "1234"},
+		{"prefix after padding", Mapper{PadLength: 6, CompanyPrefixes: map[string]string{"ABC": "A"}}, "1234", "ABC", "A001234"},
+		{"override skips transformations", Mapper{StripZeros: true, CompanyPrefixes: map[string]string{"ABC": "A-"}, Overrides: map[string]string{"001234": "X99"}}, "001234", "ABC", "X99"},
+		{"override by the trimmed number", Mapper{Overrides: map[string]string{"1234": "X99"}}, " 1234", "", "X99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Map(tt.employeeNumber, tt.companyCode); got != tt.want {
+				t.Errorf("Map(%q, %q) = %q, want %q", tt.employeeNumber, tt.companyCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"header skipped", "adp,mikealbert\n001234,X99\n", map[string]string{"001234": "X99"}, false},
+		{"no header", "001234, X99\n5678,Y1\n", map[string]string{"001234": "X99", "5678": "Y1"}, false},
+		{"comments skipped", "# exceptions\n1,2\n", map[string]string{"1": "2"}, false},
+		{"blank Mike Albert number", "1234,\n", nil, true},
+		{"wrong number of columns", "1234,X99,extra\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "overrides.csv")
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadOverrides(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("LoadOverrides() = %v, want %v", got, tt.want)
+			}
+			for adp, ma := range tt.want {
+				if got[adp] != ma {
+					t.Errorf("LoadOverrides()[%s] = %q, want %q", adp, got[adp], ma)
+				}
+			}
+		})
+	}
+}
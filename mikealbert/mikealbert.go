@@ -3,6 +3,7 @@ package mikealbert
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,6 +16,10 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// ErrUnconfirmed is returned for endpoints and driver fields not yet confirmed against the Mike Albert API
+// when the client does not allow them
+var ErrUnconfirmed = errors.New("not yet confirmed against the Mike Albert API, set mikealbert unconfirmedapi to use it")
+
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
@@ -22,13 +27,69 @@ type ErrorResponse struct {
 type Address struct {
 	Address1 string `json:"address1"`
 	Address2 string `json:"address2"`
+	City     string `json:"city"`
+	State    string `json:"state"`
 	PostCode string `json:"postCode"`
+	Country  string `json:"country,omitempty"`
+}
+
+// AddressUpdate is a partial address update, only fields that are set are sent
+type AddressUpdate struct {
+	Address1 *string `json:"address1,omitempty"`
+	Address2 *string `json:"address2,omitempty"`
+	City     *string `json:"city,omitempty"`
+	State    *string `json:"state,omitempty"`
+	PostCode *string `json:"postCode,omitempty"`
+	Country  *string `json:"country,omitempty"`
+}
+
+// DriverUpdate is a partial driver update, only fields that are set are sent
+type DriverUpdate struct {
+	FirstName       *string        `json:"firstName,omitempty"`
+	LastName        *string        `json:"lastName,omitempty"`
+	Email           *string        `json:"email,omitempty"`
+	MobilePhone     *string        `json:"mobilePhone,omitempty"`
+	HomePhone       *string        `json:"homePhone,omitempty"`
+	Address         *AddressUpdate `json:"address,omitempty"`
+	Active          *bool          `json:"active,omitempty"`
+	TerminationDate *string        `json:"terminationDate,omitempty"`
+}
+
+// unconfirmed checks if the update sets any field not yet confirmed against the Mike Albert API
+func (u DriverUpdate) unconfirmed() bool {
+	return u.FirstName != nil || u.LastName != nil || u.Email != nil || u.MobilePhone != nil || u.HomePhone != nil ||
+		u.Active != nil || u.TerminationDate != nil
 }
 
 type Driver struct {
 	Address        Address `json:"address"`
 	DriverId       *int    `json:"drvId,omitempty"`
 	EmployeeNumber *string `json:"employeeNumber,omitempty"`
+	FirstName      string  `json:"firstName,omitempty"`
+	LastName       string  `json:"lastName,omitempty"`
+	Email          string  `json:"email,omitempty"`
+	MobilePhone    string  `json:"mobilePhone,omitempty"`
+	HomePhone      string  `json:"homePhone,omitempty"`
+	Active         *bool   `json:"active,omitempty"`
+}
+
+// VehicleAllocation is a vehicle allocated to a driver, along with where it is garaged
+type VehicleAllocation struct {
+	UnitNo          string  `json:"unitNo"`
+	Vin             string  `json:"vin,omitempty"`
+	Primary         *bool   `json:"primary,omitempty"`
+	AllocationDate  string  `json:"allocationDate,omitempty"`
+	GaragingAddress Address `json:"garagingAddress"`
+}
+
+// IsPrimary checks if the vehicle is marked as the driver's primary vehicle
+func (v VehicleAllocation) IsPrimary() bool {
+	return v.Primary != nil && *v.Primary
+}
+
+// IsActive checks if the driver is active, drivers without a status are treated as active
+func (d Driver) IsActive() bool {
+	return d.Active == nil || *d.Active
 }
 
 type Authentication struct {
@@ -44,18 +105,7 @@ type Client struct {
 	authentication Authentication
 	httpClient     *http.Client
 	ratelimiter    *rate.Limiter
-}
-
-// return first n characters of a string
-func firstN(s string, n int) string {
-	i := 0
-	for j := range s {
-		if i == n {
-			return s[:j]
-		}
-		i++
-	}
-	return s
+	Unconfirmed    bool // allow endpoints and driver fields not yet confirmed against the Mike Albert API
 }
 
 // NewClient creates a new mikealbert client
@@ -242,17 +292,81 @@ func (client *Client) FindDrivers(employeeNumber string) ([]Driver, error) {
 	return resp, nil
 }
 
-// Update driver by driver ID
-func (client *Client) UpdateDriver(driverId int, address1, address2, postCode string) (*Driver, error) {
-	req := Driver{
-		Address: Address{
-			Address1: address1,
-			Address2: address2,
-			PostCode: firstN(postCode, 5),
-		},
+// Create driver, returning the driver as created including its driver ID
+func (client *Client) CreateDriver(driver Driver) (*Driver, error) {
+	if !client.Unconfirmed {
+		err := fmt.Errorf("CreateDriver: %w", ErrUnconfirmed)
+		log.Printf("%+v", err)
+		return nil, err
 	}
 
-	ab, err := json.Marshal(req)
+	ab, err := json.Marshal(driver)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	u, err := url.JoinPath(client.Endpoint, "/driver-management/driver")
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	b, err := client.makeRequest("POST", u, strings.NewReader(string(ab)))
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	var resp Driver
+	err = json.Unmarshal(b, &resp)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Get driver by driver ID
+func (client *Client) GetDriver(driverId int) (*Driver, error) {
+	if !client.Unconfirmed {
+		err := fmt.Errorf("GetDriver: %w", ErrUnconfirmed)
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	u, err := url.JoinPath(client.Endpoint, "/driver-management/driver", strconv.Itoa(driverId))
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	b, err := client.makeRequest("GET", u, nil)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	var resp Driver
+	err = json.Unmarshal(b, &resp)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Update driver by driver ID, sending only the fields set in update
+func (client *Client) UpdateDriver(driverId int, update DriverUpdate) (*Driver, error) {
+	if !client.Unconfirmed && update.unconfirmed() {
+		err := fmt.Errorf("UpdateDriver fields: %w", ErrUnconfirmed)
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	ab, err := json.Marshal(update)
 	if err != nil {
 		log.Printf("%+v", err)
 		return nil, err
@@ -279,3 +393,62 @@ func (client *Client) UpdateDriver(driverId int, address1, address2, postCode st
 
 	return &resp, nil
 }
+
+// Get the vehicles allocated to a driver by driver ID
+func (client *Client) GetVehicleAllocations(driverId int) ([]VehicleAllocation, error) {
+	if !client.Unconfirmed {
+		err := fmt.Errorf("GetVehicleAllocations: %w", ErrUnconfirmed)
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	u, err := url.JoinPath(client.Endpoint, "/driver-management/driver", strconv.Itoa(driverId), "vehicles")
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	b, err := client.makeRequest("GET", u, nil)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	var resp []VehicleAllocation
+	err = json.Unmarshal(b, &resp)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Update the garaging address of a vehicle by unit number, sending only the fields set in update
+func (client *Client) UpdateGaragingAddress(unitNo string, update AddressUpdate) error {
+	if !client.Unconfirmed {
+		err := fmt.Errorf("UpdateGaragingAddress: %w", ErrUnconfirmed)
+		log.Printf("%+v", err)
+		return err
+	}
+
+	ab, err := json.Marshal(update)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	u, err := url.JoinPath(client.Endpoint, "/vehicle-management/vehicle", unitNo, "garaging-address")
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	_, err = client.makeRequest("PATCH", u, strings.NewReader(string(ab)))
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package mikealbert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldRule describes what Mike Albert accepts in a driver field
+type FieldRule struct {
+	MaxLength     int    // most characters accepted
+	Allowed       string // punctuation accepted besides ASCII letters, digits and spaces, everything printable when blank
+	Transliterate bool   // fold accented characters and smart quotes to ASCII and drop what is still not allowed
+}
+
+// Schema is the rules for each Mike Albert driver field, by JSON field name
+type Schema map[string]FieldRule
+
+// DefaultSchema returns the field limits and character sets Mike Albert enforces on drivers
+func DefaultSchema() Schema {
+	return Schema{
+		"address1":    {MaxLength: 50, Allowed: "#-/.,'&", Transliterate: true},
+		"address2":    {MaxLength: 50, Allowed: "#-/.,'&", Transliterate: true},
+		"city":        {MaxLength: 40, Allowed: "-.'", Transliterate: true},
+		"state":       {MaxLength: 3, Allowed: "-", Transliterate: true},
+		"postCode":    {MaxLength: 10, Allowed: "-", Transliterate: true},
+		"country":     {MaxLength: 3, Transliterate: true},
+		"firstName":   {MaxLength: 40, Allowed: "-'.", Transliterate: true},
+		"lastName":    {MaxLength: 40, Allowed: "-'.", Transliterate: true},
+		"email":       {MaxLength: 100},
+		"mobilePhone": {MaxLength: 20},
+		"homePhone":   {MaxLength: 20},
+	}
+}
+
+// allowed checks if a character is accepted by the rule
+func (r FieldRule) allowed(c rune) bool {
+	switch {
+	case c > '~' || c < ' ':
+		return false
+	case len(r.Allowed) == 0:
+		return true
+	case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == ' ':
+		return true
+	}
+	return strings.ContainsRune(r.Allowed, c)
+}
+
+// Fit makes a value acceptable to Mike Albert for field, returning the value to send and a
+// description of each adjustment made. Fields that are transliterated have characters folded to
+// ASCII by fold, others dropped and are cut at the last word that fits. Fields that are not are
+// never altered: a value Mike Albert would reject is returned blank so it is not sent.
+func (s Schema) Fit(field, value string, fold func(string) string) (string, []string) {
+	rule, ok := s[field]
+	if !ok {
+		return value, nil
+	}
+
+	var adjustments []string
+	if !rule.Transliterate {
+		for _, c := range value {
+			if !rule.allowed(c) {
+				return "", []string{fmt.Sprintf("character '%c' not accepted, not sent", c)}
+			}
+		}
+		if rule.MaxLength > 0 && len(value) > rule.MaxLength {
+			return "", []string{fmt.Sprintf("longer than %d characters, not sent", rule.MaxLength)}
+		}
+		return value, nil
+	}
+
+	if folded := fold(value); folded != value {
+		adjustments = append(adjustments, "transliterated to ASCII")
+		value = folded
+	}
+
+	var removed []string
+	cleaned := strings.Map(func(c rune) rune {
+		if rule.allowed(c) {
+			return c
+		}
+		removed = append(removed, fmt.Sprintf("'%c'", c))
+		return ' '
+	}, value)
+	if len(removed) > 0 {
+		adjustments = append(adjustments, "removed "+strings.Join(removed, " "))
+		value = strings.Join(strings.Fields(cleaned), " ")
+	}
+
+	if rule.MaxLength > 0 && len(value) > rule.MaxLength {
+		value, _ = SplitAtWord(value, rule.MaxLength)
+		adjustments = append(adjustments, fmt.Sprintf("truncated to %d characters", rule.MaxLength))
+	}
+
+	return value, adjustments
+}
+
+// SplitAtWord splits s at the last space that leaves at most n characters in the first part, or at
+// n characters when there is no such space, returning the two parts
+func SplitAtWord(s string, n int) (string, string) {
+	if len(s) <= n {
+		return s, ""
+	}
+	if i := strings.LastIndex(s[:n+1], " "); i > 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+	}
+	return s[:n], strings.TrimSpace(s[n:])
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Write writes a CSV report named name to directory dir, replacing the report from any previous run.
+// Returns the path of the file written.
+func Write(dir, name string, header []string, rows [][]string) (string, error) {
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		log.Printf("%+v", err)
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.csv", name))
+	err = WriteFile(path, header, rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// WriteFile writes a CSV file with a header row to path, replacing any existing file
+func WriteFile(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	err = w.Write(header)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+	err = w.WriteAll(rows)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Read reads a CSV file with a header row, returning each row by column name
+func Read(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+	if len(records) == 0 {
+		err = fmt.Errorf("%s is empty, expected a header row", path)
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	// spreadsheets saving as CSV may start the file with a byte order mark
+	header := records[0]
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,296 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+// Run summarizes a completed sync run
+type Run struct {
+	Time     time.Time `json:"time"`
+	Workers  int       `json:"workers"`
+	Eligible int       `json:"eligible"`
+	Matched  int       `json:"matched"`
+}
+
+// Snapshot is the last value of each field known to be in sync for a Mike Albert driver, either
+// written by the sync or found already matching ADP
+type Snapshot struct {
+	Time     time.Time         `json:"time"`
+	Fields   map[string]string `json:"fields"`
+	Declined map[string]string `json:"declined,omitempty"` // ADP values not sent because an edit in Mike Albert was kept
+}
+
+// Link ties an ADP worker to a Mike Albert driver, used instead of finding the driver by employee number
+type Link struct {
+	DriverId       int       `json:"driverId"`
+	EmployeeNumber string    `json:"employeeNumber,omitempty"` // Mike Albert employee number when linked, to find the driver by
+	Name           string    `json:"name,omitempty"`
+	Source         string    `json:"source"` // manual or auto
+	Created        time.Time `json:"created"`
+}
+
+// Worker is an ADP worker's assignment status as of the last sync run
+type Worker struct {
+	Status         string    `json:"status"`
+	EmployeeNumber string    `json:"employeeNumber,omitempty"`
+	DriverId       int       `json:"driverId,omitempty"` // Mike Albert driver when the worker was terminated
+	Seen           time.Time `json:"seen"`
+}
+
+// Garaging is the address a driver's vehicles were garaged at because Mike Albert would not update
+// the driver's own address while several vehicles are allocated to them
+type Garaging struct {
+	Home   map[string]string `json:"home"`   // driver's Mike Albert address at the time, by field
+	Target map[string]string `json:"target"` // address the vehicles were garaged at, by field
+	Time   time.Time         `json:"time"`
+}
+
+// Review statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Review is an item the sync could not safely handle, queued for a fleet admin to decide on
+type Review struct {
+	Kind           string            `json:"kind"`               // what held the item back, such as name mismatch
+	Worker         string            `json:"worker,omitempty"`   // ADP associate OID, or worker ID when there is none
+	DriverId       int               `json:"driverId,omitempty"` // Mike Albert driver, 0 when not known
+	EmployeeNumber string            `json:"employeeNumber,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Reason         string            `json:"reason"`
+	Current        map[string]string `json:"current,omitempty"`  // Mike Albert values by field
+	Proposed       map[string]string `json:"proposed,omitempty"` // values the sync would send by field
+	Status         string            `json:"status"`
+	Note           string            `json:"note,omitempty"` // outcome of the decision
+	Created        time.Time         `json:"created"`
+	Seen           time.Time         `json:"seen"` // last sync run that found the item
+	Decided        time.Time         `json:"decided,omitempty"`
+}
+
+// State is the data kept between sync runs
+type State struct {
+	LastRun   *Run              `json:"lastRun,omitempty"`
+	Snapshots map[int]Snapshot  `json:"snapshots,omitempty"` // by Mike Albert DriverId
+	Links     map[string]Link   `json:"links,omitempty"`     // by ADP associate OID, or worker ID when there is none
+	Workers   map[string]Worker `json:"workers,omitempty"`   // by ADP associate OID, or worker ID when there is none
+	Reviews   map[string]Review `json:"reviews,omitempty"`   // by review ID
+	Garagings map[int]Garaging  `json:"garagings,omitempty"` // by Mike Albert driver ID
+}
+
+// Garaging returns the address a driver's vehicles were last garaged at in place of their own address
+func (s *State) Garaging(driverId int) (Garaging, bool) {
+	g, ok := s.Garagings[driverId]
+	return g, ok
+}
+
+// RecordGaraging records the address a driver's vehicles were garaged at in place of their own address
+func (s *State) RecordGaraging(driverId int, g Garaging) {
+	if s.Garagings == nil {
+		s.Garagings = make(map[int]Garaging)
+	}
+	if g.Time.IsZero() {
+		g.Time = time.Now().UTC()
+	}
+	s.Garagings[driverId] = g
+}
+
+// sameFields compares two sets of field values
+func sameFields(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, value := range a {
+		if v, ok := b[field]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// QueueReview adds an item to the review queue or refreshes it when already queued. An item that was
+// decided stays decided while the sync proposes the same values, so it is not reopened every run.
+func (s *State) QueueReview(id string, r Review) {
+	if s.Reviews == nil {
+		s.Reviews = make(map[string]Review)
+	}
+	now := time.Now().UTC()
+
+	existing, ok := s.Reviews[id]
+	switch {
+	case ok && existing.Status != ReviewPending && sameFields(existing.Proposed, r.Proposed):
+		existing.Seen = now
+		s.Reviews[id] = existing
+		return
+	case ok && existing.Status == ReviewPending:
+		r.Created = existing.Created
+	default:
+		r.Created = now
+	}
+	r.Status = ReviewPending
+	r.Seen = now
+	s.Reviews[id] = r
+}
+
+// DecideReview records the decision made on a queued item
+func (s *State) DecideReview(id, status, note string) {
+	r, ok := s.Reviews[id]
+	if !ok {
+		return
+	}
+	r.Status = status
+	r.Note = note
+	r.Decided = time.Now().UTC()
+	s.Reviews[id] = r
+}
+
+// PruneReviews removes the items no sync run has found since before, as what held them back is gone
+func (s *State) PruneReviews(before time.Time) int {
+	pruned := 0
+	for id, r := range s.Reviews {
+		if r.Seen.Before(before) {
+			delete(s.Reviews, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Worker returns an ADP worker's status as of the last sync run
+func (s *State) Worker(worker string) (Worker, bool) {
+	w, ok := s.Workers[worker]
+	return w, ok
+}
+
+// RecordWorker records an ADP worker's status for the next sync run
+func (s *State) RecordWorker(worker string, w Worker) {
+	if s.Workers == nil {
+		s.Workers = make(map[string]Worker)
+	}
+	if w.Seen.IsZero() {
+		w.Seen = time.Now().UTC()
+	}
+	s.Workers[worker] = w
+}
+
+// Link returns the Mike Albert driver linked to an ADP worker
+func (s *State) Link(worker string) (Link, bool) {
+	l, ok := s.Links[worker]
+	return l, ok
+}
+
+// AddLink links an ADP worker to a Mike Albert driver, replacing any existing link
+func (s *State) AddLink(worker string, link Link) {
+	if s.Links == nil {
+		s.Links = make(map[string]Link)
+	}
+	if link.Created.IsZero() {
+		link.Created = time.Now().UTC()
+	}
+	s.Links[worker] = link
+}
+
+// RemoveLink removes the link for an ADP worker, returning false if there was none
+func (s *State) RemoveLink(worker string) bool {
+	if _, ok := s.Links[worker]; !ok {
+		return false
+	}
+	delete(s.Links, worker)
+	return true
+}
+
+// Snapshot returns the last synced value of each field for a Mike Albert driver, nil if never synced
+func (s *State) Snapshot(driverId int) map[string]string {
+	return s.Snapshots[driverId].Fields
+}
+
+// RecordSynced records field values as in sync for a Mike Albert driver
+func (s *State) RecordSynced(driverId int, fields map[string]string) {
+	if len(fields) == 0 {
+		return
+	}
+	if s.Snapshots == nil {
+		s.Snapshots = make(map[int]Snapshot)
+	}
+
+	snapshot := s.Snapshots[driverId]
+	if snapshot.Fields == nil {
+		snapshot.Fields = make(map[string]string, len(fields))
+	}
+	for field, value := range fields {
+		snapshot.Fields[field] = value
+		delete(snapshot.Declined, field)
+	}
+	snapshot.Time = time.Now().UTC()
+	s.Snapshots[driverId] = snapshot
+}
+
+// Declined returns the ADP value of each field not sent to a Mike Albert driver because an edit in
+// Mike Albert was kept, nil if there are none
+func (s *State) Declined(driverId int) map[string]string {
+	return s.Snapshots[driverId].Declined
+}
+
+// RecordKept records an edit made in Mike Albert as the field's synced value, along with the ADP value
+// declined in its favor, so the edit is kept until ADP changes
+func (s *State) RecordKept(driverId int, field, kept, declined string) {
+	s.RecordSynced(driverId, map[string]string{field: kept})
+
+	snapshot := s.Snapshots[driverId]
+	if snapshot.Declined == nil {
+		snapshot.Declined = make(map[string]string)
+	}
+	snapshot.Declined[field] = declined
+	s.Snapshots[driverId] = snapshot
+}
+
+// Load reads state from file, a missing file is an empty state
+func Load(file string) (*State, error) {
+	var s State
+
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &s, nil
+	}
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		log.Printf("%+v", err)
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Save writes state to file, replacing it only once fully written
+func (s *State) Save(file string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	tmp := file + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	err = os.Rename(tmp, file)
+	if err != nil {
+		log.Printf("%+v", err)
+		return err
+	}
+
+	return nil
+}